@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/MikeLuu99/go-mcp/internal/papers"
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/MikeLuu99/go-mcp/internal/settings"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/redis/go-redis/v9"
+)
+
+const version = "1.0.0"
+
+func main() {
+	settings.Serve(settings.Server{
+		Name:    "combined-mcp",
+		Version: version,
+		Port:    9000,
+		Store:   "upstash-vector+redis",
+		Options: []server.ServerOption{server.WithResourceCapabilities(false, true)},
+		Open:    open,
+	})
+}
+
+func open() (*settings.Backend, error) {
+	maxIdleConns, idleErr := memory.MaxIdleConnsFromEnv()
+	memoryCfg, memoryCfgErr := memory.ConfigFromEnv()
+	papersCfg, papersCfgErr := papers.ConfigFromEnv()
+	opt, redisErr := papers.ParseRedisURL(os.Getenv("REDIS_URL"))
+	// A dedicated, pooled HTTP client lets shutdown close the Upstash
+	// connections, and its transport turns 5xx responses into retryable
+	// errors.
+	httpClient := memory.NewHTTPClient(maxIdleConns)
+	index, indexErr := memory.NewIndexFromEnv(httpClient)
+	if err := errors.Join(idleErr, memoryCfgErr, papersCfgErr, redisErr, indexErr); err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opt)
+	memoryIndex := memory.NewUpstashIndex(index)
+
+	return &settings.Backend{
+		// A wrong VECTOR_DB_URL or TOKEN stops the server here, before it
+		// accepts requests, instead of failing the first tool call.
+		Check: func(ctx context.Context) error {
+			return memory.CheckIndex(ctx, memoryIndex)
+		},
+		// Ready only when both backends answer, since either tool set is
+		// useless without its store.
+		Ping: func(ctx context.Context) error {
+			if err := memory.CheckIndex(ctx, memoryIndex); err != nil {
+				return fmt.Errorf("vector index: %w", err)
+			}
+			if err := client.Ping(ctx).Err(); err != nil {
+				return fmt.Errorf("redis: %w", err)
+			}
+			return nil
+		},
+		Register: func(s *server.MCPServer, hooks *server.Hooks, policy retry.Policy) {
+			memoryService := memory.NewService(memory.WithRetry(memoryIndex, policy), memoryCfg)
+			s.AddTools(memoryService.Tools()...)
+			s.AddPrompts(memoryService.Prompts()...)
+			s.AddResourceTemplate(memoryService.ResourceTemplate())
+			// Memories are registered as resources when a client lists them, so
+			// the list follows whatever the tools have stored or deleted since.
+			hooks.AddBeforeListResources(func(ctx context.Context, _ any, _ *mcp.ListResourcesRequest) {
+				if err := memoryService.SyncResources(ctx, s); err != nil {
+					slog.Warn("listing memory resources failed", "error", err)
+				}
+			})
+			s.AddTools(papers.NewService(papers.WithRetry(papers.WithKeyPrefix(papers.NewRedisStore(client), os.Getenv("REDIS_KEY_PREFIX")), policy), papersCfg).Tools()...)
+		},
+		Close: func() error {
+			httpClient.CloseIdleConnections()
+			return client.Close()
+		},
+	}, nil
+}
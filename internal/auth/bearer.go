@@ -0,0 +1,71 @@
+// Package auth guards the MCP HTTP endpoints.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Bearer returns next wrapped so that every request must carry the header
+// "Authorization: Bearer <token>", answering 401 otherwise. An empty token
+// disables the check and returns next unchanged, for local development.
+func Bearer(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return Guard(NewTokens(token), next)
+}
+
+// Guard is Bearer for any token in tokens, checked against the map current
+// at the time of each request. A nil tokens disables the check.
+//
+// Requests that pass carry the token's Grant in their context, where
+// Authenticated, Admin and Namespace read it. The wrapper passes the
+// original ResponseWriter through, so authenticated SSE streams are still
+// flushed as they are written.
+func Guard(tokens *Tokens, next http.Handler) http.Handler {
+	if tokens == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		grant, valid := tokens.Lookup(got)
+		if !ok || !valid {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithGrant(r.Context(), grant)))
+	})
+}
+
+type grantKey struct{}
+
+// WithGrant returns a copy of ctx carrying grant, as Guard passes on for a
+// request with a valid token.
+func WithGrant(ctx context.Context, grant Grant) context.Context {
+	return context.WithValue(ctx, grantKey{}, grant)
+}
+
+// Authenticated reports whether ctx belongs to a request that presented a
+// valid bearer token to Guard.
+func Authenticated(ctx context.Context) bool {
+	_, ok := ctx.Value(grantKey{}).(Grant)
+	return ok
+}
+
+// Admin reports whether ctx belongs to a request whose token has the admin
+// role. Without auth there is no admin.
+func Admin(ctx context.Context) bool {
+	grant, _ := ctx.Value(grantKey{}).(Grant)
+	return grant.Admin
+}
+
+// Namespace returns the namespace the request's token is confined to,
+// reporting false when it is not confined to one.
+func Namespace(ctx context.Context) (string, bool) {
+	grant, _ := ctx.Value(grantKey{}).(Grant)
+	return grant.Namespace, grant.Namespace != ""
+}
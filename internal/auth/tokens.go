@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Grant is what a bearer token gives its requests access to.
+type Grant struct {
+	// Namespace confines the token's memory requests to one namespace.
+	// Empty leaves the caller free to pick any, the default one included.
+	Namespace string
+	// Admin allows the administrative tools, such as reload-auth and
+	// purge-all-memories.
+	Admin bool
+}
+
+// Tokens maps the bearer tokens the MCP endpoints accept to what each one
+// grants. A map loaded from a file can be reloaded while the server runs;
+// the new map replaces the old one atomically, so requests in flight are
+// checked against one or the other, never a mix.
+type Tokens struct {
+	path    string
+	current atomic.Pointer[map[string]Grant]
+}
+
+// NewTokens returns a fixed map granting each of tokens admin access to
+// every namespace.
+func NewTokens(tokens ...string) *Tokens {
+	grants := make(map[string]Grant, len(tokens))
+	for _, token := range tokens {
+		grants[token] = Grant{Admin: true}
+	}
+	t := &Tokens{}
+	t.current.Store(&grants)
+	return t
+}
+
+// LoadTokens returns the map listed in the file at path, ignoring blank
+// lines and lines starting with '#'. Each line holds a token, optionally
+// followed by the namespace it is confined to ('-' for none) and the role
+// "admin":
+//
+//	alice-token  alice
+//	ops-token    -      admin
+//
+// Reload reads the file again.
+func LoadTokens(path string) (*Tokens, error) {
+	t := &Tokens{path: path}
+	if _, err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FromEnv returns the tokens listed in the file MCP_AUTH_TOKENS_FILE, or
+// else the single MCP_AUTH_TOKEN, which is an admin token. It returns nil
+// when neither is set, which disables auth.
+func FromEnv() (*Tokens, error) {
+	if path := os.Getenv("MCP_AUTH_TOKENS_FILE"); path != "" {
+		return LoadTokens(path)
+	}
+	if token := os.Getenv("MCP_AUTH_TOKEN"); token != "" {
+		return NewTokens(token), nil
+	}
+	return nil, nil
+}
+
+// Reloadable reports whether t was loaded from a file.
+func (t *Tokens) Reloadable() bool {
+	return t.path != ""
+}
+
+// Reload reads the token file again and swaps in its tokens, returning how
+// many there are. On error, including a file without tokens, the current
+// map stays in place.
+func (t *Tokens) Reload() (int, error) {
+	if t.path == "" {
+		return 0, fmt.Errorf("auth tokens were not loaded from a file")
+	}
+
+	f, err := os.Open(t.path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading auth tokens: %v", err)
+	}
+	defer f.Close()
+
+	grants := make(map[string]Grant)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		token, grant, err := parseGrant(line)
+		if err != nil {
+			return 0, fmt.Errorf("auth token file %s, line %d: %v", t.path, lineNo, err)
+		}
+		if _, exists := grants[token]; exists {
+			return 0, fmt.Errorf("auth token file %s, line %d: token is listed twice", t.path, lineNo)
+		}
+		grants[token] = grant
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading auth tokens: %v", err)
+	}
+	// An empty file would lock every client out, which is more likely a
+	// mistake than the intent.
+	if len(grants) == 0 {
+		return 0, fmt.Errorf("auth token file %s holds no tokens", t.path)
+	}
+
+	t.current.Store(&grants)
+	return len(grants), nil
+}
+
+// parseGrant parses one line of a token file.
+func parseGrant(line string) (string, Grant, error) {
+	fields := strings.Fields(line)
+	if len(fields) > 3 {
+		return "", Grant{}, fmt.Errorf("want a token, a namespace and a role, got %d fields", len(fields))
+	}
+
+	var grant Grant
+	if len(fields) > 1 && fields[1] != "-" {
+		grant.Namespace = fields[1]
+	}
+	if len(fields) > 2 {
+		if fields[2] != "admin" {
+			return "", Grant{}, fmt.Errorf("unknown role %q, want admin", fields[2])
+		}
+		grant.Admin = true
+	}
+	return fields[0], grant, nil
+}
+
+// Lookup returns what token grants, reporting false when it is not in the
+// current map.
+func (t *Tokens) Lookup(token string) (Grant, bool) {
+	var grant Grant
+	found := false
+	for want, g := range *t.current.Load() {
+		// Every token is compared so the time taken does not reveal which
+		// one matched.
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+			grant, found = g, true
+		}
+	}
+	return grant, found
+}
+
+// ReloadOnSignal reloads t every time the process receives SIGHUP, until
+// ctx is done, logging the outcome.
+func (t *Tokens) ReloadOnSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if n, err := t.Reload(); err != nil {
+					slog.Error("auth token reload failed", "error", err)
+				} else {
+					slog.Info("auth tokens reloaded", "tokens", n)
+				}
+			}
+		}
+	}()
+}
+
+// ReloadTool returns the reload-auth tool, which reloads t on demand.
+func (t *Tokens) ReloadTool() server.ServerTool {
+	tool := mcp.NewTool("reload-auth",
+		mcp.WithDescription("Reload the accepted bearer tokens and their namespaces from MCP_AUTH_TOKENS_FILE without restarting the server. Needs an admin token. Requests already running are not interrupted; if the file cannot be read or holds no tokens, the current tokens stay in place."),
+	)
+	return server.ServerTool{
+		Tool: tool,
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !Admin(ctx) {
+				return nil, fmt.Errorf("reload-auth needs a bearer token with the admin role")
+			}
+			n, err := t.Reload()
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Reloaded %d auth tokens", n)), nil
+		},
+	}
+}
@@ -0,0 +1,204 @@
+// Package config reads the optional config file the servers are given with
+// --config. Its settings are applied as the environment variables they
+// stand for, so the environment, including .env, overrides the file.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the contents of a config file, in YAML or JSON. Every setting is
+// optional; one left out keeps the value from the environment or its
+// default.
+type File struct {
+	Port      *int     `yaml:"port" json:"port"`
+	Transport string   `yaml:"transport" json:"transport"`
+	Upstash   Upstash  `yaml:"upstash" json:"upstash"`
+	Redis     Redis    `yaml:"redis" json:"redis"`
+	Timeouts  Timeouts `yaml:"timeouts" json:"timeouts"`
+	Limits    Limits   `yaml:"limits" json:"limits"`
+}
+
+// Upstash holds the Upstash Vector credentials and connection pool size.
+type Upstash struct {
+	URL          string `yaml:"url" json:"url"`
+	Token        string `yaml:"token" json:"token"`
+	MaxIdleConns *int   `yaml:"max_idle_conns" json:"max_idle_conns"`
+}
+
+// Redis holds the Redis connection settings.
+type Redis struct {
+	URL       string `yaml:"url" json:"url"`
+	KeyPrefix string `yaml:"key_prefix" json:"key_prefix"`
+}
+
+// Timeouts are durations in time.ParseDuration syntax, such as 30s.
+type Timeouts struct {
+	Shutdown string `yaml:"shutdown" json:"shutdown"`
+	Ready    string `yaml:"ready" json:"ready"`
+	Startup  string `yaml:"startup" json:"startup"`
+	Tool     string `yaml:"tool" json:"tool"`
+}
+
+// Limits bound how much clients may ask of the servers.
+type Limits struct {
+	RateLimitPerMin  *int `yaml:"rate_limit_per_min" json:"rate_limit_per_min"`
+	MinContentLength *int `yaml:"min_content_length" json:"min_content_length"`
+	MaxContentBytes  *int `yaml:"max_content_bytes" json:"max_content_bytes"`
+}
+
+// Load reads and validates the config file at path, decoding it as JSON
+// when its name ends in .json and as YAML otherwise. Unknown keys are
+// rejected, so a misspelt setting is not silently ignored.
+func Load(path string) (*File, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	var f File
+	if filepath.Ext(path) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&f)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true)
+		// An empty YAML file decodes to nothing rather than an error.
+		if err = decoder.Decode(&f); errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("config file %s:\n%w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate checks every setting and reports all of the invalid ones
+// together, one per line.
+func (f *File) Validate() error {
+	var errs []error
+	if f.Port != nil && (*f.Port < 1 || *f.Port > 65535) {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %d", *f.Port))
+	}
+	switch f.Transport {
+	case "", transport.SSE, transport.StreamableHTTP:
+	default:
+		errs = append(errs, fmt.Errorf("transport must be '%s' or '%s', got '%s'", transport.SSE, transport.StreamableHTTP, f.Transport))
+	}
+	if f.Upstash.MaxIdleConns != nil && *f.Upstash.MaxIdleConns < 1 {
+		errs = append(errs, fmt.Errorf("upstash.max_idle_conns must be positive, got %d", *f.Upstash.MaxIdleConns))
+	}
+
+	for name, value := range map[string]string{
+		"timeouts.shutdown": f.Timeouts.Shutdown,
+		"timeouts.ready":    f.Timeouts.Ready,
+		"timeouts.startup":  f.Timeouts.Startup,
+		"timeouts.tool":     f.Timeouts.Tool,
+	} {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s must be a duration such as 30s or 5m, got %q", name, value))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", name, value))
+		} else if name == "timeouts.tool" && d > 0 && d < time.Millisecond {
+			// TOOL_TIMEOUT_MS counts whole milliseconds, where a shorter
+			// timeout would become 0 and turn the deadline off.
+			errs = append(errs, fmt.Errorf("%s must be at least 1ms or 0 for none, got %s", name, value))
+		}
+	}
+
+	for name, value := range map[string]*int{
+		"limits.rate_limit_per_min": f.Limits.RateLimitPerMin,
+		"limits.min_content_length": f.Limits.MinContentLength,
+		"limits.max_content_bytes":  f.Limits.MaxContentBytes,
+	} {
+		if value != nil && *value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %d", name, *value))
+		}
+	}
+
+	// The maps above are iterated in random order, so the report is sorted
+	// to read the same every time.
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+	return errors.Join(errs...)
+}
+
+// Env returns the environment variables the settings in f stand for.
+func (f *File) Env() map[string]string {
+	vars := map[string]string{
+		"MCP_TRANSPORT":    f.Transport,
+		"VECTOR_DB_URL":    f.Upstash.URL,
+		"TOKEN":            f.Upstash.Token,
+		"REDIS_URL":        f.Redis.URL,
+		"REDIS_KEY_PREFIX": f.Redis.KeyPrefix,
+		"SHUTDOWN_TIMEOUT": f.Timeouts.Shutdown,
+		"READY_TIMEOUT":    f.Timeouts.Ready,
+		"STARTUP_TIMEOUT":  f.Timeouts.Startup,
+	}
+	// TOOL_TIMEOUT_MS counts milliseconds, where the file takes a duration.
+	if d, err := time.ParseDuration(f.Timeouts.Tool); err == nil {
+		vars["TOOL_TIMEOUT_MS"] = strconv.FormatInt(d.Milliseconds(), 10)
+	}
+	for key, value := range map[string]*int{
+		"PORT":                   f.Port,
+		"UPSTASH_MAX_IDLE_CONNS": f.Upstash.MaxIdleConns,
+		"RATE_LIMIT_PER_MIN":     f.Limits.RateLimitPerMin,
+		"MIN_CONTENT_LENGTH":     f.Limits.MinContentLength,
+		"MAX_CONTENT_BYTES":      f.Limits.MaxContentBytes,
+	} {
+		if value != nil {
+			vars[key] = strconv.Itoa(*value)
+		}
+	}
+	maps.DeleteFunc(vars, func(_, value string) bool {
+		return value == ""
+	})
+	return vars
+}
+
+// Apply loads the config file at path and sets the environment variables
+// its settings stand for, leaving any variable that already has a value.
+// An empty path means no config file was given.
+func Apply(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := Load(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range f.Env() {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
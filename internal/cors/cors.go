@@ -0,0 +1,85 @@
+// Package cors lets browser-based MCP clients reach the servers from other
+// origins.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// allowedHeaders are the request headers browsers may send cross-origin:
+// the JSON-RPC body type, the bearer token, the rate limit client ID, and
+// the Streamable HTTP session, protocol version and resumption headers.
+const allowedHeaders = "Authorization, Content-Type, X-Client-ID, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID"
+
+// exposedHeaders are the response headers browser clients may read. A
+// Streamable HTTP client must read the session ID it is given to send it
+// back with every later request.
+const exposedHeaders = "Mcp-Session-Id"
+
+// ParseOrigins splits a comma-separated ALLOWED_ORIGINS value, dropping
+// blanks. "*" allows every origin.
+func ParseOrigins(raw string) []string {
+	var origins []string
+	for _, origin := range strings.Split(raw, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Handler returns next wrapped so that requests from the given origins get
+// CORS headers, and answers their preflight OPTIONS requests itself, before
+// any authentication. Requests from other origins get no CORS headers, so
+// the browser blocks them, and their preflights are refused. With no
+// origins it returns next unchanged.
+//
+// Headers are set before next runs and the original ResponseWriter is
+// passed through, so SSE streams keep their content type and flushing.
+func Handler(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	wildcard := slices.Contains(origins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed := wildcard || slices.Contains(origins, origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !allowed {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wildcard {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", exposedHeaders)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// DELETE ends a Streamable HTTP session.
+		h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		h.Set("Access-Control-Allow-Headers", allowedHeaders)
+		h.Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
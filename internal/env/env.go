@@ -0,0 +1,97 @@
+// Package env reads typed configuration values from environment variables.
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// LoadFile sets the variables in the dotenv file at path that are not
+// already set. A missing file is not an error, since in containers the
+// configuration usually comes from the environment alone.
+func LoadFile(path string) error {
+	err := godotenv.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Int returns the integer value of the environment variable key, or
+// fallback when it is unset or empty.
+func Int(key string, fallback int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// Float returns the floating point value of the environment variable key,
+// or fallback when it is unset or empty.
+func Float(key string, fallback float64) (float64, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// Duration returns the duration value of the environment variable key, in
+// time.ParseDuration syntax, or fallback when it is unset or empty.
+func Duration(key string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 30s or 5m, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// Bool returns the boolean value of the environment variable key, in
+// strconv.ParseBool syntax, or fallback when it is unset or empty.
+func Bool(key string, fallback bool) (bool, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", key, raw)
+	}
+	return value, nil
+}
+
+// Port returns the TCP port in the environment variable key, or fallback
+// when it is unset or empty. The port must be between 1 and 65535.
+func Port(key string, fallback int) (int, error) {
+	port, err := Int(key, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s must be a TCP port between 1 and 65535, got %d", key, port)
+	}
+	return port, nil
+}
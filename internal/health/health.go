@@ -0,0 +1,67 @@
+// Package health serves the liveness and readiness probes of the MCP HTTP
+// servers.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Status is the JSON body of the liveness probe.
+type Status struct {
+	Status        string  `json:"status"`
+	Version       string  `json:"version"`
+	Uptime        string  `json:"uptime"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// Readiness is the JSON body of the readiness probe.
+type Readiness struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Paths are where Register serves the probes.
+type Paths struct {
+	Health string
+	Ready  string
+}
+
+// DefaultPaths serves the probes on /healthz and /readyz.
+var DefaultPaths = Paths{Health: "/healthz", Ready: "/readyz"}
+
+// Register adds the liveness and readiness probes to mux at paths. The
+// liveness probe always answers 200 with the server version and the time
+// since started. The readiness probe calls ping with a context bounded by
+// timeout and answers 503 when it fails, so a hung backend cannot stall it.
+func Register(mux *http.ServeMux, paths Paths, version string, started time.Time, ping func(ctx context.Context) error, timeout time.Duration) {
+	mux.HandleFunc("GET "+paths.Health, func(w http.ResponseWriter, r *http.Request) {
+		uptime := time.Since(started)
+		writeJSON(w, http.StatusOK, Status{
+			Status:        "ok",
+			Version:       version,
+			Uptime:        uptime.Round(time.Second).String(),
+			UptimeSeconds: uptime.Seconds(),
+		})
+	})
+
+	mux.HandleFunc("GET "+paths.Ready, func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := ping(ctx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, Readiness{Status: "unavailable", Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, Readiness{Status: "ready"})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,70 @@
+// Package lifecycle runs the MCP HTTP servers until they are asked to stop
+// and shuts them down gracefully.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// MCPServer is the MCP transport mounted on the HTTP server, such as a
+// *server.SSEServer or a *server.StreamableHTTPServer.
+type MCPServer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Serve runs httpServer, over HTTPS when tlsFiles is enabled and plain HTTP
+// otherwise, until it fails or the process receives SIGINT or SIGTERM. On a
+// signal it shuts down mcpServer, which must have been created with
+// httpServer as its HTTP server so that this closes its sessions and stops
+// httpServer, waits up to timeout for in-flight requests to finish and then
+// runs closers to release the storage clients.
+func Serve(httpServer *http.Server, mcpServer MCPServer, tlsFiles TLSFiles, timeout time.Duration, closers ...func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	if tlsFiles.Enabled() {
+		log.Printf("Serving HTTPS with certificate %s\n", tlsFiles.CertFile)
+		go func() {
+			errc <- httpServer.ListenAndServeTLS(tlsFiles.CertFile, tlsFiles.KeyFile)
+		}()
+	} else {
+		log.Println("Serving plain HTTP, set TLS_CERT_FILE and TLS_KEY_FILE to enable TLS")
+		go func() {
+			errc <- httpServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests\n", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// SSE streams never go idle on their own, so their sessions are closed
+	// first to let the HTTP server drain.
+	err := mcpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		err = errors.Join(err, httpServer.Close())
+	}
+	for _, closeFn := range closers {
+		err = errors.Join(err, closeFn())
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Println("Server stopped cleanly")
+	return nil
+}
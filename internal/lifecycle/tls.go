@@ -0,0 +1,47 @@
+package lifecycle
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// TLSFiles names the certificate and key Serve uses for HTTPS.
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Enabled reports whether both files are named, which selects HTTPS.
+func (f TLSFiles) Enabled() bool {
+	return f.CertFile != "" && f.KeyFile != ""
+}
+
+// TLSFromEnv reads TLS_CERT_FILE and TLS_KEY_FILE. When both are set it
+// checks that the files can be read, so a bad path fails at startup rather
+// than when the server binds. When only one is set the server falls back
+// to plain HTTP, with a warning.
+func TLSFromEnv() (TLSFiles, error) {
+	files := TLSFiles{
+		CertFile: os.Getenv("TLS_CERT_FILE"),
+		KeyFile:  os.Getenv("TLS_KEY_FILE"),
+	}
+	if !files.Enabled() {
+		if files.CertFile != "" || files.KeyFile != "" {
+			log.Println("Only one of TLS_CERT_FILE and TLS_KEY_FILE is set, TLS stays disabled")
+		}
+		return TLSFiles{}, nil
+	}
+
+	for _, file := range []struct{ key, path string }{
+		{"TLS_CERT_FILE", files.CertFile},
+		{"TLS_KEY_FILE", files.KeyFile},
+	} {
+		f, err := os.Open(file.path)
+		if err != nil {
+			return TLSFiles{}, fmt.Errorf("%s is not readable: %v", file.key, err)
+		}
+		f.Close()
+	}
+	return files, nil
+}
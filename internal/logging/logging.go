@@ -0,0 +1,85 @@
+// Package logging emits a structured log line for every tool call.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the ID the logging middleware gave the tool call
+// running in ctx, or "" outside of one.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New returns a logger writing JSON lines to w, dropping records below
+// level.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel reads a log level such as "debug", "info", "warn" or "error",
+// ignoring case. An empty string means info.
+func ParseLevel(raw string) (slog.Level, error) {
+	if raw == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return 0, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", raw)
+	}
+	return level, nil
+}
+
+// Middleware logs every tool call to logger with the tool name, a request
+// ID generated for the call, its duration and whether it succeeded. The
+// request ID is attached to the handler's context, and a handler error is
+// returned with the ID appended so it can be matched to the log line.
+func Middleware(logger *slog.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id := newRequestID()
+			ctx = context.WithValue(ctx, requestIDKey{}, id)
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			attrs := []slog.Attr{
+				slog.String("tool", request.Params.Name),
+				slog.String("request_id", id),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			switch {
+			case err != nil:
+				attrs = append(attrs, slog.String("status", "error"), slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "tool call", attrs...)
+				return result, fmt.Errorf("%w (request_id: %s)", err, id)
+			case result != nil && result.IsError:
+				attrs = append(attrs, slog.String("status", "error"))
+				logger.LogAttrs(ctx, slog.LevelWarn, "tool call", attrs...)
+			default:
+				attrs = append(attrs, slog.String("status", "ok"))
+				logger.LogAttrs(ctx, slog.LevelInfo, "tool call", attrs...)
+			}
+			return result, nil
+		}
+	}
+}
+
+// newRequestID returns 16 random hex digits.
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:]) // never fails since Go 1.24
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+func (s *Service) backfillTimestamps(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	createdAt := s.cfg.Now().UTC().Format(time.RFC3339)
+	if raw, ok := args["created_at"].(string); ok && raw != "" {
+		var err error
+		if createdAt, err = parseCreatedAt(raw); err != nil {
+			return nil, err
+		}
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{
+		IncludeMetadata: true,
+		IncludeData:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	var batch []vector.UpsertData
+	for _, v := range vectors {
+		if stamped, ok := v.Metadata[metaCreatedAt].(string); ok && stamped != "" {
+			continue
+		}
+		metadata := maps.Clone(v.Metadata)
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadata[metaCreatedAt] = createdAt
+		batch = append(batch, vector.UpsertData{
+			Id:       v.Id,
+			Data:     v.Data,
+			Metadata: metadata,
+		})
+	}
+
+	if dryRun {
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: would set created_at to %s on %d of %d memories", createdAt, len(batch), len(vectors))), nil
+	}
+
+	if len(batch) > 0 {
+		if err := s.index.UpsertDataMany(ctx, batch); err != nil {
+			return nil, fmt.Errorf("error updating memories: %v", err)
+		}
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Set created_at to %s on %d of %d memories", createdAt, len(batch), len(vectors))), nil
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// batchFailure records why one entry of a batch was rejected. ID is the
+// entry's id, or its position in the batch when it has none.
+type batchFailure struct {
+	ID     string
+	Reason string
+}
+
+// parseBatch reads the memories argument, which may be an array or a string
+// holding a JSON array.
+func parseBatch(arg any) ([]any, error) {
+	switch v := arg.(type) {
+	case []any:
+		return v, nil
+	case string:
+		var entries []any
+		if err := json.Unmarshal([]byte(v), &entries); err != nil {
+			return nil, fmt.Errorf("argument 'memories' must be a JSON array: %v", err)
+		}
+		return entries, nil
+	case nil:
+		return nil, fmt.Errorf("argument 'memories' is missing")
+	default:
+		return nil, fmt.Errorf("argument 'memories' must be a JSON array, got %T", arg)
+	}
+}
+
+// batchEntry validates one element of a batch, returning the record to
+// write or the reason it was rejected.
+func (s *Service) batchEntry(entry any) (vector.UpsertData, string) {
+	fields, ok := entry.(map[string]any)
+	if !ok {
+		return vector.UpsertData{}, "entry is not an object"
+	}
+
+	id, _ := fields["id"].(string)
+	if id == "" {
+		return vector.UpsertData{}, "missing id"
+	}
+
+	content, ok := fields["content"].(string)
+	if !ok || content == "" {
+		return vector.UpsertData{Id: id}, "missing content"
+	}
+	if err := s.checkContent(content); err != nil {
+		return vector.UpsertData{Id: id}, err.Error()
+	}
+
+	metadata, err := parseMetadata(fields["metadata"])
+	if err != nil {
+		return vector.UpsertData{Id: id}, err.Error()
+	}
+	if err := s.validateMetadata(metadata); err != nil {
+		return vector.UpsertData{Id: id}, err.Error()
+	}
+
+	meta := maps.Clone(metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	meta[metaContentHash] = contentHash(content, metadata)
+	if raw, _ := metadata[metaCreatedAt].(string); raw != "" {
+		createdAt, err := parseCreatedAt(raw)
+		if err != nil {
+			return vector.UpsertData{Id: id}, err.Error()
+		}
+		meta[metaCreatedAt] = createdAt
+	}
+
+	return vector.UpsertData{Id: id, Data: content, Metadata: meta}, ""
+}
+
+func (s *Service) batchAddMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	entries, err := parseBatch(args["memories"])
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("argument 'memories' must contain at least one memory")
+	}
+
+	var batch []vector.UpsertData
+	var failures []batchFailure
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		record, reason := s.batchEntry(entry)
+		if reason == "" && seen[record.Id] {
+			reason = "duplicate id in batch"
+		}
+		if reason != "" {
+			id := record.Id
+			if id == "" {
+				id = fmt.Sprintf("#%d", i)
+			}
+			failures = append(failures, batchFailure{ID: id, Reason: reason})
+			continue
+		}
+		seen[record.Id] = true
+		batch = append(batch, record)
+	}
+
+	if len(batch) > 0 {
+		if s.cfg.AutoTimestamps {
+			if err := s.stampBatch(ctx, batch); err != nil {
+				return nil, fmt.Errorf("error checking stored memories: %v", err)
+			}
+		}
+		if err := s.index.UpsertDataMany(ctx, batch); err != nil {
+			return nil, fmt.Errorf("error storing memories: %v", err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stored %d of %d memories", len(batch), len(entries))
+	if len(failures) > 0 {
+		fmt.Fprintf(&b, "\nFailed validation (%d):", len(failures))
+		for _, f := range failures {
+			fmt.Fprintf(&b, "\n- %s: %s", f.ID, f.Reason)
+		}
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// stampBatch stamps the timestamps of every record in batch, fetching the
+// records already stored under the same IDs in one call so re-added
+// memories keep their creation time.
+func (s *Service) stampBatch(ctx context.Context, batch []vector.UpsertData) error {
+	ids := make([]string, len(batch))
+	for i, record := range batch {
+		ids[i] = record.Id
+	}
+
+	stored, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             ids,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, record := range batch {
+		var previous map[string]any
+		// Upstash returns one entry per requested ID, empty for missing ones.
+		if i < len(stored) && stored[i].Id == record.Id {
+			previous = stored[i].Metadata
+		}
+		createdAt, _ := record.Metadata[metaCreatedAt].(string)
+		s.stamp(record.Metadata, previous, createdAt)
+	}
+	return nil
+}
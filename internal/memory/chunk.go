@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/upstash/vector-go"
+)
+
+// Metadata keys linking the chunks of a long memory back to their parent.
+const (
+	metaParentID     = "parent_id"
+	metaChunk        = "chunk"
+	metaChunks       = "chunks"
+	metaChunkOverlap = "chunk_overlap"
+)
+
+// chunkID returns the ID under which chunk n of parent is stored.
+func chunkID(parent string, n int) string {
+	return fmt.Sprintf("%s#%d", parent, n)
+}
+
+// splitChunks cuts content into windows of size runes, each sharing overlap
+// runes with the one before it.
+func splitChunks(content string, size, overlap int) []string {
+	runes := []rune(content)
+	if len(runes) <= size {
+		return []string{content}
+	}
+
+	step := size - overlap
+	var chunks []string
+	for start := 0; ; start += step {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, string(runes[start:]))
+			break
+		}
+		chunks = append(chunks, string(runes[start:end]))
+	}
+	return chunks
+}
+
+// joinChunks reverses splitChunks.
+func joinChunks(chunks []string, overlap int) string {
+	var out []rune
+	for i, chunk := range chunks {
+		runes := []rune(chunk)
+		if i > 0 {
+			runes = runes[min(overlap, len(runes)):]
+		}
+		out = append(out, runes...)
+	}
+	return string(out)
+}
+
+// storeChunks splits content and upserts every chunk in one batch, linking
+// each to id through its metadata. Every chunk also carries the entries of
+// base, which describe the memory as a whole. It returns the number of
+// chunks stored.
+func (s *Service) storeChunks(ctx context.Context, id, content string, base map[string]any) (int, error) {
+	chunks := splitChunks(content, s.cfg.ChunkSize, s.cfg.ChunkOverlap)
+
+	batch := make([]vector.UpsertData, len(chunks))
+	for n, chunk := range chunks {
+		metadata := maps.Clone(base)
+		metadata[metaParentID] = id
+		metadata[metaChunk] = n
+		metadata[metaChunks] = len(chunks)
+		metadata[metaChunkOverlap] = s.cfg.ChunkOverlap
+		batch[n] = vector.UpsertData{
+			Id:       chunkID(id, n),
+			Data:     chunk,
+			Metadata: metadata,
+		}
+	}
+
+	if err := s.index.UpsertDataMany(ctx, batch); err != nil {
+		return 0, err
+	}
+	return len(chunks), nil
+}
+
+// fetchChunked reassembles the chunked memory stored under id. found is
+// false when id has no chunks.
+func (s *Service) fetchChunked(ctx context.Context, id string) (content string, chunks int, found bool, err error) {
+	first, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{chunkID(id, 0)},
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return "", 0, false, err
+	}
+	if len(first) == 0 || first[0].Id == "" {
+		return "", 0, false, nil
+	}
+
+	total, ok := metaInt(first[0].Metadata, metaChunks)
+	if !ok || total < 1 {
+		return "", 0, false, fmt.Errorf("chunk '%s' is missing its chunk count", chunkID(id, 0))
+	}
+	overlap, _ := metaInt(first[0].Metadata, metaChunkOverlap)
+
+	ids := make([]string, total)
+	for n := range ids {
+		ids[n] = chunkID(id, n)
+	}
+
+	if s.expired(first[0].Metadata) {
+		deleteExpired(ctx, s.index, ids...)
+		return "", 0, false, nil
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:         ids,
+		IncludeData: true,
+	})
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	parts := make([]string, total)
+	for n, v := range vectors {
+		if v.Id == "" {
+			return "", 0, false, fmt.Errorf("chunk '%s' is missing", ids[n])
+		}
+		parts[n] = v.Data
+	}
+
+	return joinChunks(parts, overlap), total, true, nil
+}
+
+// metaInt reads an integer metadata value, which arrives as float64 after a
+// JSON round trip through Upstash.
+func metaInt(metadata map[string]any, key string) (int, bool) {
+	switch v := metadata[key].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	default:
+		return 0, false
+	}
+}
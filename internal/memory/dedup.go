@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/upstash/vector-go"
+)
+
+// dedupCandidates is how many search results add-to-memory inspects for a
+// duplicate, leaving room for the memory's own record and chunks.
+const dedupCandidates = 5
+
+// duplicateOf returns the existing memory most similar to content when it
+// scores at least threshold, or nil when there is none. The memory being
+// written under id, and its chunks, never count as duplicates of itself.
+func (s *Service) duplicateOf(ctx context.Context, id, content string, threshold float64) (*vector.VectorScore, error) {
+	scores, err := s.index.QueryData(ctx, vector.QueryData{
+		Data:            content,
+		TopK:            dedupCandidates,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, score := range scores {
+		if score.Score < float32(threshold) {
+			// Results come best first, so nothing further can match.
+			return nil, nil
+		}
+		owner := score.Id
+		if parent, ok := score.Metadata[metaParentID].(string); ok && parent != "" {
+			owner = parent
+		}
+		if owner == id || s.expired(score.Metadata) {
+			continue
+		}
+		score.Id = owner
+		return &score, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// deleteBatchSize is how many records delete-memories removes per request.
+// A failed request only leaves its own batch behind.
+const deleteBatchSize = 100
+
+// deletion is one memory delete-memories removes, with the IDs of the
+// records it is stored in: its own, or those of its chunks.
+type deletion struct {
+	id      string
+	records []string
+}
+
+func (s *Service) deleteMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := parseIDs(args["ids"])
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             ids,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memories: %v", err)
+	}
+
+	var deletions []deletion
+	var notFound, expired []string
+	var failures []string
+	for i, id := range ids {
+		// Upstash returns one entry per requested ID, empty for missing ones.
+		if i < len(vectors) && vectors[i].Id == id {
+			if s.expired(vectors[i].Metadata) {
+				expired = append(expired, id)
+				notFound = append(notFound, id)
+			} else {
+				deletions = append(deletions, deletion{id: id, records: []string{id}})
+			}
+			continue
+		}
+
+		_, chunks, ok, err := s.fetchChunked(ctx, id)
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s (%v)", id, err))
+		case ok:
+			records := make([]string, chunks)
+			for n := range records {
+				records[n] = chunkID(id, n)
+			}
+			deletions = append(deletions, deletion{id: id, records: records})
+		default:
+			notFound = append(notFound, id)
+		}
+	}
+
+	dryRun, _ := args["dry_run"].(bool)
+	if dryRun {
+		return previewDeletions(deletions, notFound, failures, len(ids)), nil
+	}
+	deleteExpired(ctx, s.index, expired...)
+
+	// A failed batch is reported and the remaining ones still run, so one
+	// bad request does not abort the whole cleanup.
+	deleted := 0
+	for start := 0; start < len(deletions); {
+		end, size := start, 0
+		for end < len(deletions) && (size == 0 || size+len(deletions[end].records) <= deleteBatchSize) {
+			size += len(deletions[end].records)
+			end++
+		}
+		batch := deletions[start:end]
+		start = end
+
+		var records []string
+		for _, d := range batch {
+			records = append(records, d.records...)
+		}
+		if _, err := s.index.DeleteMany(ctx, records); err != nil {
+			for _, d := range batch {
+				failures = append(failures, fmt.Sprintf("%s (%v)", d.id, err))
+			}
+			continue
+		}
+		deleted += len(batch)
+	}
+
+	result := fmt.Sprintf("Deleted %d of %d memories", deleted, len(ids))
+	if len(notFound) > 0 {
+		result += fmt.Sprintf("\nNot found: %s", strings.Join(notFound, ", "))
+	}
+	if len(failures) > 0 {
+		result += fmt.Sprintf("\nFailed: %s", strings.Join(failures, ", "))
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// previewDeletions describes what delete-memories would do without
+// touching the store: the memories it would delete, the IDs it found
+// nothing for and the lookups that failed. An expired memory counts as not
+// found, as it does when deleting.
+func previewDeletions(deletions []deletion, notFound, failures []string, total int) *mcp.CallToolResult {
+	result := fmt.Sprintf("Dry run: would delete %d of %d memories", len(deletions), total)
+	if len(deletions) > 0 {
+		ids := make([]string, len(deletions))
+		for i, d := range deletions {
+			ids[i] = d.id
+		}
+		result += fmt.Sprintf("\nWould delete: %s", strings.Join(ids, ", "))
+	}
+	if len(notFound) > 0 {
+		result += fmt.Sprintf("\nNot found: %s", strings.Join(notFound, ", "))
+	}
+	if len(failures) > 0 {
+		result += fmt.Sprintf("\nFailed: %s", strings.Join(failures, ", "))
+	}
+	return mcp.NewToolResultText(result)
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// metaExpiresAt is the metadata key holding the RFC 3339 time after which a
+// memory stored with a TTL is treated as gone. Upstash has no native expiry,
+// so expired memories are skipped, and deleted, when they are read.
+const metaExpiresAt = "expires_at"
+
+// parseTTL reads the optional ttl_seconds argument and returns the matching
+// expires_at timestamp, or "" when there is none.
+func (s *Service) parseTTL(args map[string]any) (string, error) {
+	raw, exists := args["ttl_seconds"]
+	if !exists || raw == nil {
+		return "", nil
+	}
+	seconds, ok := raw.(float64)
+	if !ok || seconds <= 0 {
+		return "", fmt.Errorf("argument 'ttl_seconds' must be a positive number")
+	}
+	ttl := time.Duration(seconds * float64(time.Second))
+	return s.cfg.Now().Add(ttl).UTC().Format(time.RFC3339Nano), nil
+}
+
+// expired reports whether metadata carries an expires_at that has passed.
+func (s *Service) expired(metadata map[string]any) bool {
+	raw, ok := metadata[metaExpiresAt].(string)
+	if !ok {
+		return false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return false
+	}
+	return !s.cfg.Now().Before(expiresAt)
+}
+
+// deleteExpired removes expired records found while reading. Failures are
+// ignored: the read already hides the records, and the next read retries.
+func deleteExpired(ctx context.Context, index Index, ids ...string) {
+	for _, id := range ids {
+		index.Delete(ctx, id)
+	}
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/upstash/vector-go"
+)
+
+// exportRecord is one memory as written by export-memories.
+type exportRecord struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+func toExportRecord(v vector.Vector) exportRecord {
+	return exportRecord{ID: v.Id, Content: v.Data, Metadata: v.Metadata}
+}
+
+func (s *Service) exportMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := math.MaxInt
+	if maxArg, exists := args["max"]; exists {
+		maxRecords, ok := maxArg.(float64)
+		if !ok || maxRecords < 1 || maxRecords != math.Trunc(maxRecords) {
+			return nil, fmt.Errorf("argument 'max' must be a positive integer")
+		}
+		limit = int(maxRecords)
+	}
+
+	format := "json"
+	if formatArg, exists := args["format"]; exists {
+		format, _ = formatArg.(string)
+	}
+
+	switch format {
+	case "json":
+		vectors, _, err := s.rangeAll(ctx, limit, vector.Range{IncludeData: true, IncludeMetadata: true})
+		if err != nil {
+			return nil, fmt.Errorf("error listing memories: %v", err)
+		}
+		records := make([]exportRecord, len(vectors))
+		for i, v := range vectors {
+			records[i] = toExportRecord(v)
+		}
+		out, err := json.Marshal(records)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding memories: %v", err)
+		}
+		return mcp.NewToolResultText(string(out)), nil
+	case "ndjson":
+		return s.exportNDJSON(ctx, request, limit)
+	default:
+		return nil, fmt.Errorf("argument 'format' must be 'json' or 'ndjson'")
+	}
+}
+
+// exportNDJSON writes one JSON object per line, for at most limit
+// memories. When the request carries a
+// progress token, each page of lines is streamed as a progress
+// notification as soon as it is read and the result only summarizes the
+// export; otherwise the lines are returned together in the result.
+func (s *Service) exportNDJSON(ctx context.Context, request mcp.CallToolRequest, limit int) (*mcp.CallToolResult, error) {
+	var token mcp.ProgressToken
+	if request.Params.Meta != nil {
+		token = request.Params.Meta.ProgressToken
+	}
+	srv := server.ServerFromContext(ctx)
+	stream := token != nil && srv != nil
+
+	var all bytes.Buffer
+	exported, pages := 0, 0
+	r := vector.Range{IncludeData: true, IncludeMetadata: true}
+	for {
+		r.Limit = min(rangePageSize, limit-exported)
+		page, err := s.index.Range(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("error listing memories: %v", err)
+		}
+
+		var lines bytes.Buffer
+		enc := json.NewEncoder(&lines)
+		for _, v := range page.Vectors {
+			if err := enc.Encode(toExportRecord(v)); err != nil {
+				return nil, fmt.Errorf("error encoding memory '%s': %v", v.Id, err)
+			}
+		}
+		exported += len(page.Vectors)
+
+		if stream && lines.Len() > 0 {
+			pages++
+			err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+				"progressToken": token,
+				"progress":      exported,
+				"message":       lines.String(),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error streaming export after %d memories: %v", exported-len(page.Vectors), err)
+			}
+		} else {
+			all.Write(lines.Bytes())
+		}
+
+		if page.NextCursor == "" || exported >= limit {
+			break
+		}
+		r.Cursor = page.NextCursor
+	}
+
+	if stream {
+		return mcp.NewToolResultText(fmt.Sprintf("Exported %d memories as NDJSON in %d progress notifications", exported, pages)), nil
+	}
+	return mcp.NewToolResultText(all.String()), nil
+}
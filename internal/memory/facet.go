@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+const (
+	// facetSamples is how many example IDs facet-memories shows per value.
+	facetSamples = 3
+	// facetUnset labels the bucket of records lacking the field.
+	facetUnset = "(unset)"
+)
+
+type facetBucket struct {
+	value string
+	count int
+	ids   []string
+}
+
+func (s *Service) facetMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	field, ok := args["field"].(string)
+	if !ok || strings.TrimSpace(field) == "" {
+		return nil, fmt.Errorf("argument 'field' is missing or empty")
+	}
+
+	maxValues := 20
+	if maxArg, exists := args["max_values"]; exists {
+		m, ok := maxArg.(float64)
+		if !ok || m < 1 {
+			return nil, fmt.Errorf("argument 'max_values' must be a positive number")
+		}
+		maxValues = int(m)
+	}
+
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{IncludeMetadata: true})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	buckets := make(map[string]*facetBucket)
+	unset := &facetBucket{value: facetUnset}
+	for _, v := range vectors {
+		bucket := unset
+		if raw, ok := v.Metadata[field]; ok {
+			value := fmt.Sprint(raw)
+			if bucket = buckets[value]; bucket == nil {
+				bucket = &facetBucket{value: value}
+				buckets[value] = bucket
+			}
+		}
+		bucket.count++
+		if len(bucket.ids) < facetSamples {
+			bucket.ids = append(bucket.ids, v.Id)
+		}
+	}
+
+	sorted := make([]*facetBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		sorted = append(sorted, bucket)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].value < sorted[j].value
+	})
+
+	omitted := 0
+	if len(sorted) > maxValues {
+		omitted = len(sorted) - maxValues
+		sorted = sorted[:maxValues]
+	}
+	if unset.count > 0 {
+		sorted = append(sorted, unset)
+	}
+
+	result := fmt.Sprintf("Facets for '%s' across %d memories:\n", field, len(vectors))
+	for _, bucket := range sorted {
+		result += fmt.Sprintf("- %s: %d (e.g. %s)\n", bucket.value, bucket.count, strings.Join(bucket.ids, ", "))
+	}
+	if omitted > 0 {
+		result += fmt.Sprintf("... and %d more values\n", omitted)
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// parseIDs reads the ids argument, which may be an array of strings or a
+// string holding a JSON array of them. Repeated IDs are dropped.
+func parseIDs(arg any) ([]string, error) {
+	var raw []any
+	switch v := arg.(type) {
+	case []any:
+		raw = v
+	case string:
+		if err := json.Unmarshal([]byte(v), &raw); err != nil {
+			return nil, fmt.Errorf("argument 'ids' must be a JSON array of strings: %v", err)
+		}
+	case nil:
+		return nil, fmt.Errorf("argument 'ids' is missing")
+	default:
+		return nil, fmt.Errorf("argument 'ids' must be a JSON array of strings, got %T", arg)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("argument 'ids' must contain at least one ID")
+	}
+
+	ids := make([]string, 0, len(raw))
+	seen := make(map[string]bool)
+	for i, entry := range raw {
+		id, ok := entry.(string)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("argument 'ids' must hold non-empty strings, got %v at position %d", entry, i)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Service) getMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := parseIDs(args["ids"])
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             ids,
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memories: %v", err)
+	}
+
+	// An ID not stored directly may be a chunked memory, which costs one
+	// more fetch; IDs missing both ways map to null rather than failing the
+	// whole call.
+	found := make(map[string]*MemoryResult, len(ids))
+	var expired []string
+	for i, id := range ids {
+		// Upstash returns one entry per requested ID, empty for missing ones.
+		if i < len(vectors) && vectors[i].Id == id {
+			v := vectors[i]
+			if s.expired(v.Metadata) {
+				expired = append(expired, id)
+			} else {
+				found[id] = &MemoryResult{ID: id, Content: v.Data, Metadata: withoutManaged(v.Metadata)}
+				continue
+			}
+		}
+
+		content, chunks, ok, err := s.fetchChunked(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving memory '%s': %v", id, err)
+		}
+		if ok {
+			found[id] = &MemoryResult{ID: id, Content: content, Chunks: chunks}
+		} else {
+			found[id] = nil
+		}
+	}
+	deleteExpired(ctx, s.index, expired...)
+
+	out, err := json.Marshal(found)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding results: %v", err)
+	}
+	return mcp.NewToolResultText(string(out)), nil
+}
@@ -0,0 +1,343 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// filterError is a syntax error in a metadata filter. Pos is the 1-based
+// character position where the problem was found.
+type filterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *filterError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+	pos  int
+}
+
+// keyword reports whether t is the case-insensitive keyword kw.
+func (t filterToken) keyword(kw string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, kw)
+}
+
+func (t filterToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokString:
+		return "string " + t.text
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// lexFilter splits filter into tokens, reporting unterminated strings and
+// characters that cannot start a token.
+func lexFilter(filter string) ([]filterToken, error) {
+	runes := []rune(filter)
+	var tokens []filterToken
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, filterToken{tokLParen, "(", start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{tokRParen, ")", start + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{tokComma, ",", start + 1})
+			i++
+		case r == '\'' || r == '"':
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, &filterError{start + 1, "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, filterToken{tokString, string(runes[start:i]), start + 1})
+		case r == '=' || r == '<' || r == '>' || r == '!':
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, &filterError{start + 1, "unexpected '!', did you mean '!='?"}
+			}
+			if op == "==" {
+				return nil, &filterError{start + 1, "unexpected '==', use '=' for equality"}
+			}
+			tokens = append(tokens, filterToken{tokOperator, op, start + 1})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokNumber, string(runes[start:i]), start + 1})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			for i < len(runes) && isFieldRune(runes[i]) {
+				if runes[i] == '[' {
+					end := i + 1
+					for end < len(runes) && runes[end] != ']' {
+						end++
+					}
+					if end >= len(runes) {
+						return nil, &filterError{i + 1, "unterminated array index"}
+					}
+					if !validArrayIndex(string(runes[i+1 : end])) {
+						return nil, &filterError{i + 1, fmt.Sprintf("invalid array index '%s'", string(runes[i:end+1]))}
+					}
+					i = end
+				}
+				i++
+			}
+			tokens = append(tokens, filterToken{tokIdent, string(runes[start:i]), start + 1})
+		default:
+			return nil, &filterError{start + 1, fmt.Sprintf("unexpected character '%c'", r)}
+		}
+	}
+
+	tokens = append(tokens, filterToken{tokEOF, "", len(runes) + 1})
+	return tokens, nil
+}
+
+func isFieldRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '.' || r == '['
+}
+
+// validArrayIndex accepts the contents of an Upstash array index: a
+// non-negative position, or '#' optionally followed by a negative offset.
+func validArrayIndex(index string) bool {
+	if rest, ok := strings.CutPrefix(index, "#"); ok {
+		if rest == "" {
+			return true
+		}
+		digits, ok := strings.CutPrefix(rest, "-")
+		return ok && digits != "" && strings.Trim(digits, "0123456789") == ""
+	}
+	return index != "" && strings.Trim(index, "0123456789") == ""
+}
+
+// filterParser checks a token stream against the Upstash metadata filter
+// grammar:
+//
+//	expr    = and { OR and }
+//	and     = primary { AND primary }
+//	primary = "(" expr ")" | HAS [NOT] FIELD field | field condition
+//	condition = op value | [NOT] GLOB string | [NOT] IN "(" value { "," value } ")" | [NOT] CONTAINS value
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// validateFilter parses filter without running it, returning a
+// *filterError describing the first syntax error.
+func validateFilter(filter string) error {
+	if strings.TrimSpace(filter) == "" {
+		return &filterError{1, "filter is empty"}
+	}
+
+	tokens, err := lexFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	p := &filterParser{tokens: tokens}
+	if err := p.expr(); err != nil {
+		return err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return p.errorf(tok, "expected AND, OR or end of filter, got %s", tok)
+	}
+	return nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...any) error {
+	return &filterError{tok.pos, fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) expr() error {
+	if err := p.and(); err != nil {
+		return err
+	}
+	for p.peek().keyword("OR") {
+		p.next()
+		if err := p.and(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) and() error {
+	if err := p.primary(); err != nil {
+		return err
+	}
+	for p.peek().keyword("AND") {
+		p.next()
+		if err := p.primary(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *filterParser) primary() error {
+	tok := p.next()
+
+	if tok.kind == tokLParen {
+		if err := p.expr(); err != nil {
+			return err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return p.errorf(closing, "expected ')' to close '(' at position %d, got %s", tok.pos, closing)
+		}
+		return nil
+	}
+
+	if tok.keyword("HAS") {
+		if p.peek().keyword("NOT") {
+			p.next()
+		}
+		if field := p.next(); !field.keyword("FIELD") {
+			return p.errorf(field, "expected FIELD after HAS, got %s", field)
+		}
+		if field := p.next(); !isField(field) {
+			return p.errorf(field, "expected field name, got %s", field)
+		}
+		return nil
+	}
+
+	if !isField(tok) {
+		return p.errorf(tok, "expected field name or '(', got %s", tok)
+	}
+	return p.condition(tok)
+}
+
+func (p *filterParser) condition(field filterToken) error {
+	tok := p.next()
+
+	if tok.kind == tokOperator {
+		return p.value()
+	}
+
+	negated := tok.keyword("NOT")
+	if negated {
+		tok = p.next()
+	}
+
+	switch {
+	case tok.keyword("GLOB"):
+		if pattern := p.next(); pattern.kind != tokString {
+			return p.errorf(pattern, "expected quoted pattern after GLOB, got %s", pattern)
+		}
+		return nil
+	case tok.keyword("CONTAINS"):
+		return p.value()
+	case tok.keyword("IN"):
+		if open := p.next(); open.kind != tokLParen {
+			return p.errorf(open, "expected '(' after IN, got %s", open)
+		}
+		for {
+			if err := p.value(); err != nil {
+				return err
+			}
+			sep := p.next()
+			if sep.kind == tokRParen {
+				return nil
+			}
+			if sep.kind != tokComma {
+				return p.errorf(sep, "expected ',' or ')' in IN list, got %s", sep)
+			}
+		}
+	case negated:
+		return p.errorf(tok, "expected GLOB, IN or CONTAINS after NOT, got %s", tok)
+	default:
+		return p.errorf(tok, "expected comparison operator after field '%s', got %s", field.text, tok)
+	}
+}
+
+func (p *filterParser) value() error {
+	tok := p.next()
+	if tok.kind == tokString || tok.kind == tokNumber || tok.keyword("TRUE") || tok.keyword("FALSE") {
+		return nil
+	}
+	return p.errorf(tok, "expected a string, number or boolean value, got %s", tok)
+}
+
+// filterKeywords cannot be used as bare field names.
+var filterKeywords = []string{"AND", "OR", "NOT", "GLOB", "IN", "CONTAINS", "HAS", "FIELD", "TRUE", "FALSE"}
+
+func isField(tok filterToken) bool {
+	if tok.kind != tokIdent {
+		return false
+	}
+	for _, kw := range filterKeywords {
+		if tok.keyword(kw) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) validateFilter(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	filter, ok := args["filter"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'filter' is missing or not a string")
+	}
+
+	if err := validateFilter(filter); err != nil {
+		return mcp.NewToolResultText("invalid: " + err.Error()), nil
+	}
+	return mcp.NewToolResultText("valid"), nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/agnivade/levenshtein"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// defaultIDDistance is the largest edit distance find-memory-by-id accepts
+// when none is given.
+const defaultIDDistance = 3
+
+type idMatch struct {
+	id       string
+	distance int
+	content  string
+}
+
+func (s *Service) findMemoryByID(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	query, ok := args["id"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("argument 'id' is missing or empty")
+	}
+
+	maxDistance := defaultIDDistance
+	if distanceArg, exists := args["max_distance"]; exists {
+		d, ok := distanceArg.(float64)
+		if !ok || d < 0 || d != math.Trunc(d) {
+			return nil, fmt.Errorf("argument 'max_distance' must be a non-negative integer")
+		}
+		maxDistance = int(d)
+	}
+
+	limit := 10
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok || l < 1 {
+			return nil, fmt.Errorf("argument 'limit' must be a positive number")
+		}
+		limit = int(l)
+	}
+
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{
+		IncludeMetadata: true,
+		IncludeData:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	var matches []idMatch
+	seen := make(map[string]bool)
+	for _, v := range vectors {
+		if s.expired(v.Metadata) {
+			continue
+		}
+		// A chunk is found by the ID of the memory it belongs to.
+		id := v.Id
+		if parent, ok := v.Metadata[metaParentID].(string); ok && parent != "" {
+			id = parent
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		distance := levenshtein.ComputeDistance(strings.ToLower(query), strings.ToLower(id))
+		if distance <= maxDistance {
+			matches = append(matches, idMatch{id: id, distance: distance, content: v.Data})
+		}
+	}
+
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No memory IDs within distance %d of '%s'", maxDistance, query)), nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].id < matches[j].id
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	result := fmt.Sprintf("Found %d memories with IDs near '%s':\n", len(matches), query)
+	for i, m := range matches {
+		result += fmt.Sprintf("%d. ID: %s, Distance: %d, Content: %s\n", i+1, m.id, m.distance, preview(m.content, previewRunes))
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MemoryResult is one memory in the JSON output of search-memory and
+// get-memory. Score is only set on search results, Why and Explain only on
+// searches with explain, Boosted only on searches with a recency boost,
+// Chunks only on a reassembled chunked memory and Vector only when
+// include_vector or include_vectors is set.
+type MemoryResult struct {
+	ID       string         `json:"id"`
+	Score    *float32       `json:"score,omitempty"`
+	Boosted  *float64       `json:"boosted,omitempty"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Created  string         `json:"created_at,omitempty"`
+	Updated  string         `json:"updated_at,omitempty"`
+	Chunks   int            `json:"chunks,omitempty"`
+	Vector   []float32      `json:"vector,omitempty"`
+	Why      string         `json:"why,omitempty"`
+	Explain  *Explanation   `json:"explain,omitempty"`
+}
+
+// Explanation is the debugging detail explain adds to a JSON search result,
+// for tuning embeddings against the scores they produce.
+type Explanation struct {
+	// RawScore is the similarity Upstash returned, before any recency boost.
+	RawScore float32 `json:"raw_score"`
+	Query    string  `json:"query"`
+	// ContentLength counts the characters of the stored content, not of the
+	// preview returned.
+	ContentLength int `json:"content_length"`
+}
+
+// parseJSONFormat reads the optional format argument, reporting whether it
+// asks for JSON output. fallback is used when the argument is absent.
+func parseJSONFormat(args map[string]any, fallback bool) (bool, error) {
+	raw, exists := args["format"]
+	if !exists {
+		return fallback, nil
+	}
+	format, ok := raw.(string)
+	if !ok {
+		return false, fmt.Errorf("argument 'format' must be a string")
+	}
+	switch format {
+	case "text":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("argument 'format' must be 'text' or 'json', got '%s'", format)
+	}
+}
+
+// encodeResults renders results as a JSON array, empty rather than null
+// when nothing matched.
+func encodeResults(results []MemoryResult) (string, error) {
+	if results == nil {
+		results = []MemoryResult{}
+	}
+	out, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("error encoding results: %v", err)
+	}
+	return string(out), nil
+}
+
+func jsonResult(results []MemoryResult) (*mcp.CallToolResult, error) {
+	out, err := encodeResults(results)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(out), nil
+}
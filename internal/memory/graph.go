@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// rangePageSize is how many records each Range call asks for when paging
+// through the whole index.
+const rangePageSize = 100
+
+// GraphNode is one memory in the similarity graph with its neighbors.
+type GraphNode struct {
+	ID        string          `json:"id"`
+	Neighbors []GraphNeighbor `json:"neighbors"`
+}
+
+// GraphNeighbor is an edge of the similarity graph.
+type GraphNeighbor struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// Graph is the result of the memory-graph tool.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	// Truncated is set when the index held more memories than max_nodes.
+	Truncated bool `json:"truncated"`
+}
+
+func (s *Service) memoryGraph(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	threshold, err := s.similarityThreshold(args)
+	if err != nil {
+		return nil, err
+	}
+
+	maxNodes := s.cfg.GraphMaxNodes
+	if maxArg, exists := args["max_nodes"]; exists {
+		m, ok := maxArg.(float64)
+		if !ok || m < 1 {
+			return nil, fmt.Errorf("argument 'max_nodes' must be a positive number")
+		}
+		maxNodes = min(int(m), s.cfg.GraphMaxNodes)
+	}
+
+	vectors, truncated, err := s.rangeAll(ctx, maxNodes, vector.Range{IncludeVectors: true})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	graph := Graph{Nodes: make([]GraphNode, len(vectors)), Truncated: truncated}
+	for i, v := range vectors {
+		graph.Nodes[i] = GraphNode{ID: v.Id, Neighbors: []GraphNeighbor{}}
+	}
+
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			score, err := cosineSimilarity(vectors[i].Vector, vectors[j].Vector)
+			if err != nil || score < threshold {
+				continue
+			}
+			graph.Nodes[i].Neighbors = append(graph.Nodes[i].Neighbors, GraphNeighbor{ID: vectors[j].Id, Score: score})
+			graph.Nodes[j].Neighbors = append(graph.Nodes[j].Neighbors, GraphNeighbor{ID: vectors[i].Id, Score: score})
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		sort.Slice(node.Neighbors, func(a, b int) bool {
+			return node.Neighbors[a].Score > node.Neighbors[b].Score
+		})
+	}
+
+	out, err := json.Marshal(graph)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding graph: %v", err)
+	}
+	return mcp.NewToolResultText(string(out)), nil
+}
+
+// rangeAll pages through the index with the options in r, returning at most
+// limit records and whether more were left unread.
+func (s *Service) rangeAll(ctx context.Context, limit int, r vector.Range) ([]vector.Vector, bool, error) {
+	var out []vector.Vector
+	r.Cursor = ""
+	for {
+		r.Limit = min(rangePageSize, limit-len(out))
+		page, err := s.index.Range(ctx, r)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, page.Vectors...)
+
+		if page.NextCursor == "" {
+			return out, false, nil
+		}
+		if len(out) >= limit {
+			return out, true, nil
+		}
+		r.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// metaContentHash is the metadata key holding the hash of what was stored,
+// so re-adding identical content can skip the upsert.
+const metaContentHash = "content_hash"
+
+// contentHash identifies a memory's content together with the metadata
+// supplied for it.
+func contentHash(content string, metadata map[string]any) string {
+	// json.Marshal sorts map keys, so equal metadata always encodes alike.
+	encoded, _ := json.Marshal(metadata)
+	sum := sha256.Sum256(append([]byte(content+"\x00"), encoded...))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// importEntry validates one record of an export-memories dump, returning
+// the record to write or the reason it was skipped. Unlike batchEntry it
+// keeps the managed metadata keys, so a restored memory keeps its
+// timestamps, expiry and chunk links.
+func importEntry(entry any) (vector.UpsertData, string) {
+	fields, ok := entry.(map[string]any)
+	if !ok {
+		return vector.UpsertData{}, "entry is not an object"
+	}
+
+	id, _ := fields["id"].(string)
+	if id == "" {
+		return vector.UpsertData{}, "missing id"
+	}
+
+	content, ok := fields["content"].(string)
+	if !ok || content == "" {
+		return vector.UpsertData{Id: id}, "missing content"
+	}
+
+	var metadata map[string]any
+	switch v := fields["metadata"].(type) {
+	case nil:
+		metadata = map[string]any{}
+	case map[string]any:
+		metadata = maps.Clone(v)
+	default:
+		return vector.UpsertData{Id: id}, fmt.Sprintf("metadata must be a JSON object, got %T", v)
+	}
+	if hash, _ := metadata[metaContentHash].(string); hash == "" {
+		metadata[metaContentHash] = contentHash(content, withoutManaged(metadata))
+	}
+
+	return vector.UpsertData{Id: id, Data: content, Metadata: metadata}, ""
+}
+
+func (s *Service) importMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseBatch(args["memories"])
+	if err != nil {
+		return nil, err
+	}
+
+	var batch []vector.UpsertData
+	var failures []batchFailure
+	seen := make(map[string]bool)
+	for i, entry := range entries {
+		record, reason := importEntry(entry)
+		if reason == "" && seen[record.Id] {
+			reason = "duplicate id in import"
+		}
+		if reason != "" {
+			id := record.Id
+			if id == "" {
+				id = fmt.Sprintf("#%d", i)
+			}
+			failures = append(failures, batchFailure{ID: id, Reason: reason})
+			continue
+		}
+		seen[record.Id] = true
+		batch = append(batch, record)
+	}
+
+	// Upserting overwrites memories already stored under the same IDs, so
+	// importing the same dump twice leaves the store unchanged.
+	for start := 0; start < len(batch); start += rangePageSize {
+		end := min(start+rangePageSize, len(batch))
+		if err := s.index.UpsertDataMany(ctx, batch[start:end]); err != nil {
+			return nil, fmt.Errorf("error importing memories after %d of %d: %v", start, len(batch), err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Inserted %d of %d memories, skipped %d", len(batch), len(entries), len(failures))
+	if len(failures) > 0 {
+		fmt.Fprintf(&b, "\nSkipped (%d):", len(failures))
+		for _, f := range failures {
+			fmt.Fprintf(&b, "\n- %s: %s", f.ID, f.Reason)
+		}
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
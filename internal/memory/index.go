@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/upstash/vector-go"
+)
+
+// Index is the subset of the Upstash Vector API used by the memory tools,
+// with every call bound to the context of the tool request that made it.
+// NewUpstashIndex adapts a *vector.Index, and tests substitute an in-memory
+// mock.
+type Index interface {
+	UpsertData(ctx context.Context, u vector.UpsertData) error
+	UpsertDataMany(ctx context.Context, u []vector.UpsertData) error
+	// Update changes the data or metadata of an existing record in place,
+	// reporting false when no record has the ID.
+	Update(ctx context.Context, u vector.Update) (bool, error)
+	// Delete removes the record with the ID, reporting false when there
+	// was none.
+	Delete(ctx context.Context, id string) (bool, error)
+	// DeleteMany removes the records with the IDs in one request,
+	// reporting how many of them there were.
+	DeleteMany(ctx context.Context, ids []string) (int, error)
+	QueryData(ctx context.Context, q vector.QueryData) ([]vector.VectorScore, error)
+	Fetch(ctx context.Context, f vector.Fetch) ([]vector.Vector, error)
+	Range(ctx context.Context, r vector.Range) (vector.RangeVectors, error)
+	Reset(ctx context.Context) error
+	Info(ctx context.Context) (vector.IndexInfo, error)
+	// Namespace returns an Index whose record operations are confined to
+	// the namespace ns. The empty name is the default namespace. Info still
+	// describes the whole index.
+	Namespace(ns string) Index
+}
+
+// namespaceClient is the part of the Upstash API that both *vector.Index
+// and *vector.Namespace provide.
+type namespaceClient interface {
+	UpsertData(u vector.UpsertData) error
+	UpsertDataMany(u []vector.UpsertData) error
+	Update(u vector.Update) (bool, error)
+	Delete(id string) (bool, error)
+	DeleteMany(ids []string) (int, error)
+	QueryData(q vector.QueryData) ([]vector.VectorScore, error)
+	Fetch(f vector.Fetch) ([]vector.Vector, error)
+	Range(r vector.Range) (vector.RangeVectors, error)
+	Reset() error
+}
+
+// NewHTTPClient returns the HTTP client for an Upstash Vector index. It has
+// its own connection pool, keeping up to maxIdleConns connections to
+// Upstash open between calls where http.DefaultTransport keeps two per
+// host, so concurrent tool calls reuse connections instead of dialing new
+// ones and shutdown can close them. 5xx responses become retryable errors.
+func NewHTTPClient(maxIdleConns int) *http.Client {
+	pool := http.DefaultTransport.(*http.Transport).Clone()
+	pool.MaxIdleConns = maxIdleConns
+	pool.MaxIdleConnsPerHost = maxIdleConns
+	return &http.Client{Transport: &retry.Transport{Base: pool}}
+}
+
+// MaxIdleConnsFromEnv reads UPSTASH_MAX_IDLE_CONNS, the number of idle
+// connections NewHTTPClient keeps, which defaults to 10.
+func MaxIdleConnsFromEnv() (int, error) {
+	maxIdleConns, err := env.Int("UPSTASH_MAX_IDLE_CONNS", 10)
+	if err != nil {
+		return 0, err
+	}
+	if maxIdleConns < 1 {
+		return 0, fmt.Errorf("UPSTASH_MAX_IDLE_CONNS must be positive, got %d", maxIdleConns)
+	}
+	return maxIdleConns, nil
+}
+
+// NewIndexFromEnv returns a client for the Upstash Vector index named by
+// VECTOR_DB_URL and TOKEN, sending its requests through httpClient. It
+// fails when either variable is empty.
+func NewIndexFromEnv(httpClient *http.Client) (*vector.Index, error) {
+	url, token := os.Getenv("VECTOR_DB_URL"), os.Getenv("TOKEN")
+	if url == "" || token == "" {
+		return nil, errors.New("VECTOR_DB_URL and TOKEN must be set, in the environment or in .env")
+	}
+	return vector.NewIndexWith(vector.Options{
+		Url:    url,
+		Token:  token,
+		Client: httpClient,
+	}), nil
+}
+
+// CheckIndex asks index for its info to confirm that it is reachable and
+// accepts the configured credentials, so that a bad VECTOR_DB_URL or TOKEN
+// is reported at startup and by the readiness probe rather than by the
+// first tool call.
+func CheckIndex(ctx context.Context, index Index) error {
+	_, err := index.Info(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case isAuthError(err):
+		return fmt.Errorf("upstash vector rejected the credentials, check TOKEN: %w", err)
+	default:
+		return fmt.Errorf("upstash vector index is unreachable, check VECTOR_DB_URL: %w", err)
+	}
+}
+
+// isAuthError reports whether err is Upstash refusing the token, which it
+// signals only through the error text.
+func isAuthError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unauthorized") || strings.Contains(msg, "invalid token") || strings.Contains(msg, "forbidden")
+}
+
+// UpstashIndex is an Index backed by an Upstash Vector client.
+//
+// The Upstash client takes no context, so a call whose context ends first
+// returns ctx.Err() immediately while the HTTP request finishes in the
+// background and its result is discarded.
+type UpstashIndex struct {
+	index *vector.Index
+	// records serves the record operations: index itself, or one of its
+	// namespaces.
+	records namespaceClient
+}
+
+// NewUpstashIndex returns an Index using the default namespace of index.
+func NewUpstashIndex(index *vector.Index) *UpstashIndex {
+	return &UpstashIndex{index: index, records: index}
+}
+
+func (u *UpstashIndex) Namespace(ns string) Index {
+	if ns == "" {
+		return &UpstashIndex{index: u.index, records: u.index}
+	}
+	return &UpstashIndex{index: u.index, records: u.index.Namespace(ns)}
+}
+
+func (u *UpstashIndex) UpsertData(ctx context.Context, data vector.UpsertData) error {
+	_, err := withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, u.records.UpsertData(data)
+	})
+	return err
+}
+
+func (u *UpstashIndex) UpsertDataMany(ctx context.Context, data []vector.UpsertData) error {
+	_, err := withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, u.records.UpsertDataMany(data)
+	})
+	return err
+}
+
+func (u *UpstashIndex) Update(ctx context.Context, update vector.Update) (bool, error) {
+	return withContext(ctx, func() (bool, error) {
+		return u.records.Update(update)
+	})
+}
+
+func (u *UpstashIndex) Delete(ctx context.Context, id string) (bool, error) {
+	return withContext(ctx, func() (bool, error) {
+		return u.records.Delete(id)
+	})
+}
+
+func (u *UpstashIndex) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	return withContext(ctx, func() (int, error) {
+		return u.records.DeleteMany(ids)
+	})
+}
+
+func (u *UpstashIndex) QueryData(ctx context.Context, q vector.QueryData) ([]vector.VectorScore, error) {
+	return withContext(ctx, func() ([]vector.VectorScore, error) {
+		return u.records.QueryData(q)
+	})
+}
+
+func (u *UpstashIndex) Fetch(ctx context.Context, f vector.Fetch) ([]vector.Vector, error) {
+	return withContext(ctx, func() ([]vector.Vector, error) {
+		return u.records.Fetch(f)
+	})
+}
+
+func (u *UpstashIndex) Range(ctx context.Context, r vector.Range) (vector.RangeVectors, error) {
+	return withContext(ctx, func() (vector.RangeVectors, error) {
+		return u.records.Range(r)
+	})
+}
+
+func (u *UpstashIndex) Reset(ctx context.Context) error {
+	_, err := withContext(ctx, func() (struct{}, error) {
+		return struct{}{}, u.records.Reset()
+	})
+	return err
+}
+
+func (u *UpstashIndex) Info(ctx context.Context) (vector.IndexInfo, error) {
+	return withContext(ctx, u.index.Info)
+}
+
+// withContext runs call and returns its result, or ctx.Err() if ctx ends
+// before call returns.
+func withContext[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
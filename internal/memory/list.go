@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// previewRunes is how much of each memory's content list-memories shows.
+const previewRunes = 80
+
+func (s *Service) listMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	cursor := ""
+	if cursorArg, exists := args["cursor"]; exists {
+		c, ok := cursorArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'cursor' must be a string")
+		}
+		cursor = c
+	}
+
+	limit := 20
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok || l < 1 {
+			return nil, fmt.Errorf("argument 'limit' must be a positive number")
+		}
+		limit = int(l)
+	}
+
+	page, err := s.index.Range(ctx, vector.Range{
+		Cursor:      cursor,
+		Limit:       limit,
+		IncludeData: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	if len(page.Vectors) == 0 {
+		if cursor == "" {
+			return mcp.NewToolResultText("No memories stored yet"), nil
+		}
+		return mcp.NewToolResultText("No more memories"), nil
+	}
+
+	result := fmt.Sprintf("Listing %d memories:\n", len(page.Vectors))
+	for i, v := range page.Vectors {
+		result += fmt.Sprintf("%d. ID: %s, Content: %s\n", i+1, v.Id, preview(v.Data, previewRunes))
+	}
+	if page.NextCursor != "" {
+		result += fmt.Sprintf("Next cursor: %s", page.NextCursor)
+	} else {
+		result += "No more memories"
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+// preview shortens content to at most limit runes, marking the cut with
+// an ellipsis.
+func preview(content string, limit int) string {
+	runes := []rune(content)
+	if len(runes) <= limit {
+		return content
+	}
+	return string(runes[:limit]) + "..."
+}
+
+func (s *Service) listMemoriesSorted(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	field, ok := args["field"].(string)
+	if !ok || strings.TrimSpace(field) == "" {
+		return nil, fmt.Errorf("argument 'field' is missing or empty")
+	}
+
+	ascending := true
+	if orderArg, exists := args["order"]; exists {
+		order, ok := orderArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'order' must be a string")
+		}
+
+		switch order {
+		case "asc":
+			ascending = true
+		case "desc":
+			ascending = false
+		default:
+			return nil, fmt.Errorf("argument 'order' must be 'asc' or 'desc', got '%s'", order)
+		}
+	}
+
+	limit := 20
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok {
+			return nil, fmt.Errorf("argument 'limit' must be a number")
+		}
+		limit = int(l)
+	}
+	if limit < 1 {
+		return nil, fmt.Errorf("argument 'limit' must be a positive number")
+	}
+
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{
+		IncludeMetadata: true,
+		IncludeData:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	sort.SliceStable(vectors, func(i, j int) bool {
+		a, aok := vectors[i].Metadata[field]
+		b, bok := vectors[j].Metadata[field]
+		if !aok || !bok {
+			// Records lacking the field go last whatever the order.
+			return aok && !bok
+		}
+		if ascending {
+			return lessMeta(a, b)
+		}
+		return lessMeta(b, a)
+	})
+
+	if len(vectors) == 0 {
+		return mcp.NewToolResultText("No memories stored"), nil
+	}
+	if len(vectors) > limit {
+		vectors = vectors[:limit]
+	}
+
+	result := fmt.Sprintf("Listing %d memories by '%s':\n", len(vectors), field)
+	for i, v := range vectors {
+		value := "(missing)"
+		if raw, ok := v.Metadata[field]; ok {
+			value = fmt.Sprint(raw)
+		}
+		result += fmt.Sprintf("%d. ID: %s, %s: %s, Content: %s\n", i+1, v.Id, field, value, v.Data)
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+// lessMeta orders metadata values: numbers numerically and before anything
+// else, then everything else by its string form.
+func lessMeta(a, b any) bool {
+	an, aNum := metaFloat(a)
+	bn, bNum := metaFloat(b)
+	switch {
+	case aNum && bNum:
+		return an < bn
+	case aNum != bNum:
+		return aNum
+	default:
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	}
+}
+
+// metaFloat reads a numeric metadata value in any of the forms it may take.
+func metaFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,1043 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/sanitize"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/upstash/vector-go"
+)
+
+// Config tunes the behavior of the memory tools.
+type Config struct {
+	// MinContentLength is the minimum number of runes add-to-memory accepts.
+	MinContentLength int
+	// MaxContentBytes is the largest content, in bytes, that the tools
+	// writing memories accept. Zero disables the limit.
+	MaxContentBytes int
+	// SanitizeInput strips control characters other than newlines and tabs
+	// from the content add-to-memory stores.
+	SanitizeInput bool
+	// ChunkSize is the length in runes of each chunk when add-to-memory is
+	// asked to chunk long content.
+	ChunkSize int
+	// ChunkOverlap is how many runes consecutive chunks share.
+	ChunkOverlap int
+	// VectorPreviewDims caps how many vector dimensions get-memory prints
+	// when include_vector is set.
+	VectorPreviewDims int
+	// SimilarityThreshold is the default minimum cosine similarity for every
+	// tool that decides whether two memories are alike, such as the edges
+	// of memory-graph and add-to-memory dedup. Each tool accepts a
+	// per-call 'threshold' override.
+	SimilarityThreshold float64
+	// GraphMaxNodes caps how many memories memory-graph compares, since the
+	// work grows with the square of the node count.
+	GraphMaxNodes int
+	// AutoTimestamps stamps created_at and updated_at into the metadata of
+	// every memory add-to-memory writes.
+	AutoTimestamps bool
+	// DefaultTopK is how many results search-memory returns when the call
+	// does not pass top_k.
+	DefaultTopK int
+	// MMRLambda is the default balance search-memory's diversity mode
+	// strikes between relevance (1) and novelty (0).
+	MMRLambda float64
+	// TopKRounding is how a fractional top_k is turned into a count.
+	TopKRounding Rounding
+	// StrictTopK rejects a top_k that is not a whole number instead of
+	// rounding it.
+	StrictTopK bool
+	// MetadataSchema, when set, is the JSON schema the caller-supplied
+	// metadata of every memory written must satisfy. Nil accepts any
+	// metadata.
+	MetadataSchema *MetadataSchema
+	// SearchSessionTTL is how long a session's last search stays available
+	// to refine-search after the session goes quiet.
+	SearchSessionTTL time.Duration
+	// Now returns the current time. It defaults to time.Now and exists so
+	// tests can control the clock.
+	Now func() time.Time
+}
+
+// DefaultConfig returns the configuration used when nothing is overridden.
+func DefaultConfig() Config {
+	return Config{
+		MinContentLength:    1,
+		MaxContentBytes:     32 * 1024,
+		SanitizeInput:       true,
+		ChunkSize:           1000,
+		ChunkOverlap:        100,
+		VectorPreviewDims:   16,
+		SimilarityThreshold: 0.8,
+		GraphMaxNodes:       200,
+		AutoTimestamps:      true,
+		DefaultTopK:         5,
+		MMRLambda:           0.5,
+		SearchSessionTTL:    30 * time.Minute,
+		Now:                 time.Now,
+	}
+}
+
+// Validate reports configuration values that cannot work.
+func (c Config) Validate() error {
+	if c.MaxContentBytes < 0 {
+		return fmt.Errorf("max content bytes must not be negative, got %d", c.MaxContentBytes)
+	}
+	if c.ChunkSize < 1 {
+		return fmt.Errorf("chunk size must be positive, got %d", c.ChunkSize)
+	}
+	if c.ChunkOverlap < 0 || c.ChunkOverlap >= c.ChunkSize {
+		return fmt.Errorf("chunk overlap must be between 0 and the chunk size, got %d", c.ChunkOverlap)
+	}
+	if c.SimilarityThreshold < -1 || c.SimilarityThreshold > 1 {
+		return fmt.Errorf("similarity threshold must be between -1 and 1, got %g", c.SimilarityThreshold)
+	}
+	if c.GraphMaxNodes < 1 {
+		return fmt.Errorf("graph max nodes must be positive, got %d", c.GraphMaxNodes)
+	}
+	if c.DefaultTopK < 1 {
+		return fmt.Errorf("default top_k must be positive, got %d", c.DefaultTopK)
+	}
+	if c.MMRLambda < 0 || c.MMRLambda > 1 {
+		return fmt.Errorf("MMR lambda must be between 0 and 1, got %g", c.MMRLambda)
+	}
+	return nil
+}
+
+// ConfigFromEnv reads MIN_CONTENT_LENGTH, MAX_CONTENT_BYTES, SANITIZE_INPUT,
+// CHUNK_SIZE, CHUNK_OVERLAP, VECTOR_PREVIEW_DIMS, SIMILARITY_THRESHOLD,
+// GRAPH_MAX_NODES, AUTO_TIMESTAMPS, DEFAULT_TOP_K, MMR_LAMBDA,
+// TOP_K_ROUNDING, STRICT_TOP_K, SEARCH_SESSION_TTL and METADATA_SCHEMA over
+// DefaultConfig and validates the result. Every value that does not parse
+// is reported, not just the first.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	var errs []error
+	var err error
+	cfg.MinContentLength, err = env.Int("MIN_CONTENT_LENGTH", cfg.MinContentLength)
+	errs = append(errs, err)
+	cfg.MaxContentBytes, err = env.Int("MAX_CONTENT_BYTES", cfg.MaxContentBytes)
+	errs = append(errs, err)
+	cfg.SanitizeInput, err = env.Bool("SANITIZE_INPUT", cfg.SanitizeInput)
+	errs = append(errs, err)
+	cfg.ChunkSize, err = env.Int("CHUNK_SIZE", cfg.ChunkSize)
+	errs = append(errs, err)
+	cfg.ChunkOverlap, err = env.Int("CHUNK_OVERLAP", cfg.ChunkOverlap)
+	errs = append(errs, err)
+	cfg.VectorPreviewDims, err = env.Int("VECTOR_PREVIEW_DIMS", cfg.VectorPreviewDims)
+	errs = append(errs, err)
+	cfg.SimilarityThreshold, err = env.Float("SIMILARITY_THRESHOLD", cfg.SimilarityThreshold)
+	errs = append(errs, err)
+	cfg.GraphMaxNodes, err = env.Int("GRAPH_MAX_NODES", cfg.GraphMaxNodes)
+	errs = append(errs, err)
+	cfg.AutoTimestamps, err = env.Bool("AUTO_TIMESTAMPS", cfg.AutoTimestamps)
+	errs = append(errs, err)
+	cfg.DefaultTopK, err = env.Int("DEFAULT_TOP_K", cfg.DefaultTopK)
+	errs = append(errs, err)
+	cfg.MMRLambda, err = env.Float("MMR_LAMBDA", cfg.MMRLambda)
+	errs = append(errs, err)
+	cfg.TopKRounding, err = ParseRounding(os.Getenv("TOP_K_ROUNDING"))
+	errs = append(errs, err)
+	cfg.StrictTopK, err = env.Bool("STRICT_TOP_K", cfg.StrictTopK)
+	errs = append(errs, err)
+	cfg.SearchSessionTTL, err = env.Duration("SEARCH_SESSION_TTL", cfg.SearchSessionTTL)
+	errs = append(errs, err)
+	if path := os.Getenv("METADATA_SCHEMA"); path != "" {
+		cfg.MetadataSchema, err = LoadMetadataSchema(path)
+		errs = append(errs, err)
+	}
+	// Validation is left until every value parsed, since a value that did
+	// not would be reported twice.
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Service implements the memory tools on top of a vector index.
+type Service struct {
+	index    Index
+	cfg      Config
+	searches *searchSessions
+	listed   *resourceSet
+}
+
+// NewService returns a Service storing memories in index.
+func NewService(index Index, cfg Config) *Service {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Service{
+		index:    callerIndex{index},
+		cfg:      cfg,
+		searches: newSearchSessions(cfg.SearchSessionTTL),
+		listed:   &resourceSet{},
+	}
+}
+
+// Tools returns the memory tools ready to be registered on an MCP server.
+func (s *Service) Tools() []server.ServerTool {
+	addToMemory := mcp.NewTool("add-to-memory",
+		mcp.WithDescription("Store user information, preferences, and behaviors. Run on explicit commands ('remember this') or implicitly when detecting significant user traits, preferences, or patterns. Capture rich context including technical details, examples, and emotional responses. You should think about running this after every user message. YOU MUST USE THE TOOLS/CALL TO USE THIS. NOTHING ELSE. THIS IS NOT A RESOURCE. IT'S A TOOL."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Unique identifier for the memory"),
+		),
+		mcp.WithString("content",
+			mcp.Required(),
+			mcp.Description("The memory content to store"),
+		),
+		mcp.WithObject("metadata",
+			mcp.Description("Additional metadata for the memory as a JSON object, stored alongside the content and usable in filters. A string holding a JSON object is also accepted."),
+		),
+		mcp.WithBoolean("chunk",
+			mcp.Description("Split long content into overlapping chunks stored as '{id}#{n}', so searches can surface the most relevant part (default: false)"),
+		),
+		mcp.WithString("created_at",
+			mcp.Description("Explicit creation time as an RFC 3339 timestamp, overriding the one stamped automatically"),
+		),
+		mcp.WithBoolean("skip_unchanged",
+			mcp.Description("Skip the write when the memory already stored under this ID has identical content and metadata (default: false)"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to store the memory in, isolating it from other namespaces (default: the default namespace)"),
+		),
+		mcp.WithNumber("ttl_seconds",
+			mcp.Description("Expire the memory this many seconds from now; expired memories are no longer returned by search-memory or get-memory and are deleted when next read (default: never expire)"),
+		),
+		mcp.WithBoolean("dedup",
+			mcp.Description("Skip the insert when another memory is already this similar to the content, reporting the ID it matched (default: false)"),
+		),
+		mcp.WithNumber("threshold",
+			mcp.Description(fmt.Sprintf("Minimum similarity score at which dedup treats an existing memory as a duplicate (default: %g)", s.cfg.SimilarityThreshold)),
+		),
+	)
+
+	batchAddMemories := mcp.NewTool("batch-add-memories",
+		mcp.WithDescription("Store many memories in a single write, for bulk imports. Entries that fail validation are reported by ID and skipped without aborting the rest of the batch."),
+		mcp.WithArray("memories",
+			mcp.Required(),
+			mcp.Description("Memories to store as {id, content, metadata} objects; metadata is optional. A string holding a JSON array is also accepted."),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":       map[string]any{"type": "string"},
+					"content":  map[string]any{"type": "string"},
+					"metadata": map[string]any{"type": "object"},
+				},
+				"required": []string{"id", "content"},
+			}),
+		),
+	)
+
+	updateMemory := mcp.NewTool("update-memory",
+		mcp.WithDescription("Update an existing memory in place. Only the fields given are changed: new content replaces the stored content, and metadata keys are merged into the stored metadata, with a null value removing a key. Use add-to-memory to create a memory."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the memory to update"),
+		),
+		mcp.WithString("content",
+			mcp.Description("New content for the memory (default: keep the stored content)"),
+		),
+		mcp.WithObject("metadata",
+			mcp.Description("Metadata keys to set, or null to remove, as a JSON object; keys not mentioned are kept"),
+		),
+	)
+
+	updateMemoryMetadata := mcp.NewTool("update-memory-metadata",
+		mcp.WithDescription("Replace the metadata of an existing memory, keeping its content and vector exactly as stored. Unlike update-memory, keys not given are removed. Memories stored in chunks are not supported."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("ID of the memory to update"),
+		),
+		mcp.WithObject("metadata",
+			mcp.Required(),
+			mcp.Description("New metadata for the memory as a JSON object, replacing all caller-supplied keys"),
+		),
+	)
+
+	renameMemory := mcp.NewTool("rename-memory",
+		mcp.WithDescription("Move a memory to a new ID, keeping its content and metadata, and delete the old ID. Memories stored in chunks cannot be renamed."),
+		mcp.WithString("old_id",
+			mcp.Required(),
+			mcp.Description("Current ID of the memory"),
+		),
+		mcp.WithString("new_id",
+			mcp.Required(),
+			mcp.Description("ID to move the memory to"),
+		),
+		mcp.WithBoolean("overwrite",
+			mcp.Description("Replace a memory that already has new_id instead of refusing (default: false)"),
+		),
+	)
+
+	searchMemory := mcp.NewTool("search-memory",
+		mcp.WithDescription("Search user memories and patterns. Run when explicitly asked or when context about user's past choices would be helpful. Uses semantic matching to find relevant details across related experiences. If you do not have prior knowledge about something, this is the perfect tool to call. YOU MUST USE THE TOOLS/CALL TO USE THIS. THIS IS NOT A RESOURCE. IT'S A TOOL."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Search query text"),
+		),
+		mcp.WithNumber("top_k",
+			mcp.Description(fmt.Sprintf("Number of results to return (default: %d). A fractional value is truncated or rounded as the server is configured, or rejected in strict mode.", s.cfg.DefaultTopK)),
+		),
+		mcp.WithString("order",
+			mcp.Description("Result order by similarity: 'desc' (most similar first, default) or 'asc' (least similar first). Applied to the retrieved top_k results."),
+			mcp.Enum("desc", "asc"),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("Annotate each result with why it matched: its score, the constraints applied and the query terms found in it. In JSON format each result also gets an 'explain' object with its raw_score, the query and its content_length, for debugging rankings (default: false)"),
+		),
+		mcp.WithString("filter",
+			mcp.Description("Only return memories whose metadata matches this Upstash filter expression, e.g. \"tags CONTAINS 'project:alpha'\". Results are still ranked by similarity."),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Only keep results whose metadata 'tags' field holds all of these tags, applied after the similarity search"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithBoolean("tags_any",
+			mcp.Description("Keep results holding at least one of the tags instead of all of them (default: false)"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to search; memories in other namespaces are never returned (default: the default namespace)"),
+		),
+		mcp.WithNumber("min_score",
+			mcp.Description("Drop results whose similarity score is below this cutoff, between 0 and 1, e.g. 0.75 (default: 0, keep everything)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'text' (default) or 'json', a JSON array of {id, score, content, metadata} objects"),
+			mcp.Enum("text", "json"),
+		),
+		mcp.WithNumber("preview_chars",
+			mcp.Description("Truncate each result's content to this many characters, marking the cut with '...' (default: 0, no truncation)"),
+		),
+		mcp.WithString("template",
+			mcp.Description("Go text/template rendering each result in text format, one line per result, over the fields id, score, content and metadata, e.g. \"{{.id}}: {{.content}}\" (default: the built-in format)"),
+		),
+		mcp.WithNumber("recency_boost",
+			mcp.Description("Re-rank the retrieved results by blending similarity with how recently each memory was created (its created_at), from 0 (pure similarity, default) to 1 (pure recency). Memories without created_at are ranked by similarity alone."),
+		),
+		mcp.WithBoolean("include_vectors",
+			mcp.Description("Add each result's stored embedding vector, untruncated, to the JSON output; ignored in text format (default: false)"),
+		),
+		mcp.WithBoolean("diversity",
+			mcp.Description("Rerank with Maximal Marginal Relevance so near-duplicate memories do not crowd out different ones: twice top_k candidates are retrieved and top_k picked balancing relevance against similarity to those already picked (default: false)"),
+		),
+		mcp.WithNumber("diversity_lambda",
+			mcp.Description(fmt.Sprintf("Balance of the diversity reranking, from 0 (novelty only) to 1 (relevance only) (default: %g)", s.cfg.MMRLambda)),
+		),
+	)
+
+	getMemory := mcp.NewTool("get-memory",
+		mcp.WithDescription("Get a specific memory by ID"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Memory ID to retrieve"),
+		),
+		mcp.WithBoolean("include_vector",
+			mcp.Description("Append the stored embedding vector to the response, truncated for size (default: false)"),
+		),
+		mcp.WithBoolean("suggest_on_miss",
+			mcp.Description("When no memory has this exact ID, suggest the memory whose content is semantically closest to the ID (default: false)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'text' (default) or 'json', a JSON array holding the memory, or empty when it is not found. In JSON the vector is not truncated and suggest_on_miss is ignored."),
+			mcp.Enum("text", "json"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to read the memory from (default: the default namespace)"),
+		),
+	)
+
+	getMemories := mcp.NewTool("get-memories",
+		mcp.WithDescription("Get several memories by ID in a single fetch. Returns a JSON object mapping each ID to its {id, content, metadata}, or to null when no memory has that ID."),
+		mcp.WithArray("ids",
+			mcp.Required(),
+			mcp.Description("IDs of the memories to retrieve; a string holding a JSON array is also accepted"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to read from (default: the default namespace)"),
+		),
+	)
+
+	refineSearch := mcp.NewTool("refine-search",
+		mcp.WithDescription("Re-run this session's previous search-memory query with tweaked parameters, without repeating the query text"),
+		mcp.WithNumber("top_k",
+			mcp.Description("Number of results to return (default: the previous search's top_k)"),
+		),
+		mcp.WithString("order",
+			mcp.Description("Result order by similarity: 'desc' or 'asc' (default: the previous search's order)"),
+			mcp.Enum("desc", "asc"),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("Annotate each result with why it matched (default: the previous search's setting)"),
+		),
+		mcp.WithString("filter",
+			mcp.Description("Metadata filter expression; an empty string removes it (default: the previous search's filter)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Tags results must hold; an empty array removes the constraint (default: the previous search's tags)"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithBoolean("tags_any",
+			mcp.Description("Keep results holding any of the tags instead of all (default: the previous search's setting)"),
+		),
+		mcp.WithNumber("min_score",
+			mcp.Description("Minimum similarity score, 0 to keep everything (default: the previous search's cutoff)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'text' or 'json' (default: the previous search's format)"),
+			mcp.Enum("text", "json"),
+		),
+		mcp.WithNumber("recency_boost",
+			mcp.Description("Weight of recency in the ranking, from 0 to 1 (default: the previous search's boost)"),
+		),
+		mcp.WithNumber("preview_chars",
+			mcp.Description("Truncate each result's content to this many characters, 0 for none (default: the previous search's setting)"),
+		),
+		mcp.WithString("template",
+			mcp.Description("Go text/template rendering each text result, empty for the built-in format (default: the previous search's template)"),
+		),
+		mcp.WithBoolean("include_vectors",
+			mcp.Description("Add each result's embedding vector to the JSON output (default: the previous search's setting)"),
+		),
+		mcp.WithBoolean("diversity",
+			mcp.Description("Rerank for diversity with Maximal Marginal Relevance (default: the previous search's setting)"),
+		),
+		mcp.WithNumber("diversity_lambda",
+			mcp.Description("Balance of the diversity reranking, from 0 to 1 (default: the previous search's setting)"),
+		),
+	)
+
+	compareMemories := mcp.NewTool("compare-memories",
+		mcp.WithDescription("Compare two memories by the cosine similarity of their stored vectors"),
+		mcp.WithString("id_a",
+			mcp.Required(),
+			mcp.Description("ID of the first memory"),
+		),
+		mcp.WithString("id_b",
+			mcp.Required(),
+			mcp.Description("ID of the second memory"),
+		),
+	)
+
+	listMemories := mcp.NewTool("list-memories",
+		mcp.WithDescription("Page through every stored memory without a query, showing each ID with a preview of its content"),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor returned by the previous page (default: start from the beginning)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of memories per page (default: 20)"),
+		),
+	)
+
+	findMemoryByID := mcp.NewTool("find-memory-by-id",
+		mcp.WithDescription("Find memories whose ID is close to a half-remembered one, by edit distance, closest first with a preview of each memory's content"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("The ID, or as much of it as you remember"),
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description("Largest edit distance, ignoring case, between the given ID and a match (default: 3)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of matches to return (default: 10)"),
+		),
+	)
+
+	listMemoriesSorted := mcp.NewTool("list-memories-sorted",
+		mcp.WithDescription("List stored memories sorted by a metadata field, such as a numeric 'priority' or a 'created_at' timestamp. Memories without the field are listed last."),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.Description("Metadata field to sort by"),
+		),
+		mcp.WithString("order",
+			mcp.Description("Sort order: 'asc' (default) or 'desc'"),
+			mcp.Enum("asc", "desc"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of memories to list (default: 20)"),
+		),
+	)
+
+	recentMemories := mcp.NewTool("recent-memories",
+		mcp.WithDescription("List the most recently added memories, newest created_at first, without a query. Memories without created_at are listed last."),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of memories to list (default: 10)"),
+		),
+	)
+
+	facetMemories := mcp.NewTool("facet-memories",
+		mcp.WithDescription("Group stored memories by the value of a metadata field, returning each value's count and example IDs, plus an '(unset)' bucket for memories without the field"),
+		mcp.WithString("field",
+			mcp.Required(),
+			mcp.Description("Metadata field to group by, e.g. 'category'"),
+		),
+		mcp.WithNumber("max_values",
+			mcp.Description("Maximum number of distinct values to return, most frequent first (default: 20)"),
+		),
+	)
+
+	tagUsageStats := mcp.NewTool("tag-usage-stats",
+		mcp.WithDescription("Report how many memories carry each tag in their metadata 'tags' field and how many bytes of content they hold, most used tags first. A memory with several tags counts toward each; memories without tags are grouped as '(untagged)'."),
+	)
+
+	validateFilter := mcp.NewTool("validate-filter",
+		mcp.WithDescription("Check a metadata filter expression against the Upstash filter syntax without running a query. Returns 'valid' or the first syntax error with its character position."),
+		mcp.WithString("filter",
+			mcp.Required(),
+			mcp.Description("Filter expression, e.g. \"genre = 'jazz' AND (year >= 1950 OR tags CONTAINS 'live')\""),
+		),
+	)
+
+	memoryGraph := mcp.NewTool("memory-graph",
+		mcp.WithDescription("Export a similarity graph of stored memories as a JSON adjacency list, linking memories whose cosine similarity is at or above a threshold"),
+		mcp.WithNumber("threshold",
+			mcp.Description(fmt.Sprintf("Minimum cosine similarity for an edge (default: %g)", s.cfg.SimilarityThreshold)),
+		),
+		mcp.WithNumber("max_nodes",
+			mcp.Description(fmt.Sprintf("Maximum number of memories to include (default and cap: %d)", s.cfg.GraphMaxNodes)),
+		),
+	)
+
+	exportMemories := mcp.NewTool("export-memories",
+		mcp.WithDescription("Export every stored memory as {id, content, metadata} records, either as one JSON array or as newline-delimited JSON. With 'ndjson' and a progress token on the request, records are streamed page by page in progress notifications instead of being buffered into the result."),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default) or 'ndjson'"),
+			mcp.Enum("json", "ndjson"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to export (default: the default namespace)"),
+		),
+		mcp.WithNumber("max",
+			mcp.Description("Export at most this many memories, to keep the response within MCP message limits (default: all)"),
+		),
+	)
+
+	importMemories := mcp.NewTool("import-memories",
+		mcp.WithDescription("Restore memories from the JSON array written by export-memories, overwriting memories already stored under the same IDs so re-importing a dump is safe. Records are written as exported, including their timestamps; invalid records are reported by ID and skipped."),
+		mcp.WithArray("memories",
+			mcp.Required(),
+			mcp.Description("Memories to restore as {id, content, metadata} objects, or a string holding the JSON array written by export-memories"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":       map[string]any{"type": "string"},
+					"content":  map[string]any{"type": "string"},
+					"metadata": map[string]any{"type": "object"},
+				},
+				"required": []string{"id", "content"},
+			}),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to restore into (default: the default namespace)"),
+		),
+	)
+
+	backfillTimestamps := mcp.NewTool("backfill-timestamps",
+		mcp.WithDescription("Set created_at on stored memories that lack one by re-writing them, leaving memories that already have one untouched"),
+		mcp.WithString("created_at",
+			mcp.Description("RFC 3339 timestamp to assign (default: the current time)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report how many memories would be updated without writing anything (default: false)"),
+		),
+	)
+
+	deleteMemories := mcp.NewTool("delete-memories",
+		mcp.WithDescription("Delete several memories by ID in batched requests. Returns how many were deleted and lists the IDs that were not found or could not be deleted; a failed batch does not stop the others."),
+		mcp.WithArray("ids",
+			mcp.Required(),
+			mcp.Description("IDs of the memories to delete; a string holding a JSON array is also accepted"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to delete from (default: the default namespace)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Report which memories would be deleted and which IDs were not found, without deleting anything (default: false)"),
+		),
+	)
+
+	purgeAllMemories := mcp.NewTool("purge-all-memories",
+		mcp.WithDescription("Delete every stored memory, or every memory of one namespace. Destructive: only runs for an admin token and when 'confirm' is exactly '"+PurgeConfirmation+"', unless dry_run only previews it."),
+		mcp.WithString("confirm",
+			mcp.Description("Must be exactly '"+PurgeConfirmation+"' to proceed; not needed with dry_run"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to purge, leaving the others untouched (default: the default namespace)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description(fmt.Sprintf("Report how many memories would be deleted, and the first %d IDs, without deleting anything (default: false)", purgePreviewIDs)),
+		),
+	)
+
+	clearAllMemories := mcp.NewTool("clear-all-memories",
+		mcp.WithDescription("Reset the index, deleting every memory of the default namespace or of one namespace. Destructive: only runs for an admin token and when 'confirm' is exactly '"+PurgeConfirmation+"'."),
+		mcp.WithString("confirm",
+			mcp.Required(),
+			mcp.Description("Must be exactly '"+PurgeConfirmation+"' to proceed"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to clear, leaving the others untouched (default: the default namespace)"),
+		),
+	)
+
+	return []server.ServerTool{
+		{Tool: addToMemory, Handler: s.addToMemory},
+		{Tool: batchAddMemories, Handler: s.batchAddMemories},
+		{Tool: updateMemory, Handler: s.updateMemory},
+		{Tool: updateMemoryMetadata, Handler: s.updateMemoryMetadata},
+		{Tool: renameMemory, Handler: s.renameMemory},
+		{Tool: searchMemory, Handler: s.searchMemory},
+		{Tool: getMemory, Handler: s.getMemory},
+		{Tool: getMemories, Handler: s.getMemories},
+		{Tool: refineSearch, Handler: s.refineSearch},
+		{Tool: compareMemories, Handler: s.compareMemories},
+		{Tool: listMemories, Handler: s.listMemories},
+		{Tool: findMemoryByID, Handler: s.findMemoryByID},
+		{Tool: listMemoriesSorted, Handler: s.listMemoriesSorted},
+		{Tool: recentMemories, Handler: s.recentMemories},
+		{Tool: facetMemories, Handler: s.facetMemories},
+		{Tool: tagUsageStats, Handler: s.tagUsageStats},
+		{Tool: validateFilter, Handler: s.validateFilter},
+		{Tool: memoryGraph, Handler: s.memoryGraph},
+		{Tool: exportMemories, Handler: s.exportMemories},
+		{Tool: importMemories, Handler: s.importMemories},
+		{Tool: backfillTimestamps, Handler: s.backfillTimestamps},
+		{Tool: deleteMemories, Handler: s.deleteMemories},
+		{Tool: purgeAllMemories, Handler: s.purgeAllMemories},
+		{Tool: clearAllMemories, Handler: s.clearAllMemories},
+	}
+}
+
+// checkContent enforces the configured bounds on memory content. The
+// minimum counts runes, the maximum counts bytes since that is what the
+// embedding request and the process memory pay for.
+func (s *Service) checkContent(content string) error {
+	if length := utf8.RuneCountInString(content); length < s.cfg.MinContentLength {
+		return fmt.Errorf("content must be at least %d characters long, got %d", s.cfg.MinContentLength, length)
+	}
+	if s.cfg.MaxContentBytes > 0 && len(content) > s.cfg.MaxContentBytes {
+		return fmt.Errorf("content must be at most %d bytes long, got %d", s.cfg.MaxContentBytes, len(content))
+	}
+	return nil
+}
+
+func (s *Service) addToMemory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'id' is missing or not a string")
+	}
+
+	content, ok := args["content"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'content' is missing or not a string")
+	}
+	if s.cfg.SanitizeInput {
+		content = sanitize.Text(content)
+	}
+
+	if err := s.checkContent(content); err != nil {
+		return nil, err
+	}
+
+	metadata, err := parseMetadata(args["metadata"])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+	hash := contentHash(content, metadata)
+
+	var createdAt string
+	raw, _ := args["created_at"].(string)
+	if raw == "" {
+		raw, _ = metadata[metaCreatedAt].(string)
+	}
+	if raw != "" {
+		if createdAt, err = parseCreatedAt(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt, err := s.parseTTL(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if dedup, _ := args["dedup"].(bool); dedup {
+		threshold, err := s.similarityThreshold(args)
+		if err != nil {
+			return nil, err
+		}
+		match, err := s.duplicateOf(ctx, id, content, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("error checking for duplicates: %v", err)
+		}
+		if match != nil {
+			return mcp.NewToolResultText(fmt.Sprintf("Skipped memory with ID: %s, it duplicates memory '%s' (score: %.4f)", id, match.Id, match.Score)), nil
+		}
+	}
+
+	chunk, _ := args["chunk"].(bool)
+	chunk = chunk && utf8.RuneCountInString(content) > s.cfg.ChunkSize
+
+	// A chunked memory keeps its memory-wide metadata on every chunk, so
+	// the first chunk stands in for the whole.
+	target := id
+	if chunk {
+		target = chunkID(id, 0)
+	}
+
+	skipUnchanged, _ := args["skip_unchanged"].(bool)
+	var stored map[string]any
+	if skipUnchanged || s.cfg.AutoTimestamps {
+		if stored, err = s.storedMetadata(ctx, target); err != nil {
+			return nil, fmt.Errorf("error checking stored memory: %v", err)
+		}
+	}
+
+	// A new TTL, or an expired record, is a change even when the content
+	// is the same.
+	if skipUnchanged && stored != nil && stored[metaContentHash] == hash && expiresAt == "" && !s.expired(stored) {
+		return mcp.NewToolResultText(fmt.Sprintf("No change to memory with ID: %s", id)), nil
+	}
+
+	meta := maps.Clone(metadata)
+	if meta == nil {
+		meta = map[string]any{}
+	}
+	meta[metaContentHash] = hash
+	s.stamp(meta, stored, createdAt)
+	if expiresAt != "" {
+		meta[metaExpiresAt] = expiresAt
+	}
+
+	if chunk {
+		chunks, err := s.storeChunks(ctx, id, content, meta)
+		if err != nil {
+			return nil, fmt.Errorf("error storing memory: %v", err)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Successfully stored memory with ID: %s in %d chunks", id, chunks)), nil
+	}
+
+	err = s.index.UpsertData(ctx, vector.UpsertData{
+		Id:       id,
+		Data:     content,
+		Metadata: meta,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error storing memory: %v", err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully stored memory with ID: %s", id)), nil
+}
+
+// storedMetadata returns the metadata of the record stored under id, or nil
+// when there is none.
+func (s *Service) storedMetadata(ctx context.Context, id string) (map[string]any, error) {
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{id},
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 || vectors[0].Id == "" {
+		return nil, nil
+	}
+	if vectors[0].Metadata == nil {
+		return map[string]any{}, nil
+	}
+	return vectors[0].Metadata, nil
+}
+
+func (s *Service) getMemory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	s, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'id' is missing or not a string")
+	}
+
+	includeVector, _ := args["include_vector"].(bool)
+	suggestOnMiss, _ := args["suggest_on_miss"].(bool)
+	asJSON, err := parseJSONFormat(args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{id},
+		IncludeData:     true,
+		IncludeMetadata: true,
+		IncludeVectors:  includeVector,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+
+	if len(vectors) == 1 && vectors[0].Id == id && s.expired(vectors[0].Metadata) {
+		deleteExpired(ctx, s.index, id)
+		vectors = nil
+	}
+
+	if len(vectors) == 0 || vectors[0].Id != id {
+		content, chunks, found, err := s.fetchChunked(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving memory: %v", err)
+		}
+		if asJSON {
+			var results []MemoryResult
+			if found {
+				results = append(results, MemoryResult{ID: id, Content: content, Chunks: chunks})
+			}
+			return jsonResult(results)
+		}
+		if found {
+			return mcp.NewToolResultText(fmt.Sprintf("Memory ID: %s (reassembled from %d chunks)\nContent: %s", id, chunks, content)), nil
+		}
+
+		notFound := fmt.Sprintf("Memory with ID '%s' not found", id)
+		if suggestOnMiss {
+			suggestion, err := s.suggest(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("error searching for a suggestion: %v", err)
+			}
+			if suggestion != nil {
+				notFound += fmt.Sprintf("\nDid you mean: %s (score: %.4f)\nContent: %s", suggestion.Id, suggestion.Score, suggestion.Data)
+			}
+		}
+		return mcp.NewToolResultText(notFound), nil
+	}
+
+	created, updated := timestamps(vectors[0].Metadata)
+	if asJSON {
+		return jsonResult([]MemoryResult{{
+			ID:       vectors[0].Id,
+			Content:  vectors[0].Data,
+			Metadata: withoutManaged(vectors[0].Metadata),
+			Created:  created,
+			Updated:  updated,
+			Vector:   vectors[0].Vector,
+		}})
+	}
+
+	result := fmt.Sprintf("Memory ID: %s\nContent: %s", vectors[0].Id, vectors[0].Data)
+	if created != "" {
+		result += "\nCreated: " + created
+	}
+	if updated != "" {
+		result += "\nUpdated: " + updated
+	}
+	if metadata := formatMetadata(vectors[0].Metadata); metadata != "" {
+		result += "\nMetadata: " + metadata
+	}
+	if includeVector {
+		result += "\n" + formatVector(vectors[0].Vector, s.cfg.VectorPreviewDims)
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
+
+// suggest returns the memory semantically closest to the missing ID id, or
+// nil when the index has nothing to offer.
+func (s *Service) suggest(ctx context.Context, id string) (*vector.VectorScore, error) {
+	scores, err := s.index.QueryData(ctx, vector.QueryData{
+		Data:            id,
+		TopK:            1,
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 || s.expired(scores[0].Metadata) {
+		return nil, nil
+	}
+	return &scores[0], nil
+}
+
+func (s *Service) compareMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	idA, ok := args["id_a"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'id_a' is missing or not a string")
+	}
+
+	idB, ok := args["id_b"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'id_b' is missing or not a string")
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:            []string{idA, idB},
+		IncludeVectors: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching memories: %v", err)
+	}
+
+	// Upstash returns one entry per requested ID, empty for missing ones.
+	for i, id := range []string{idA, idB} {
+		if i >= len(vectors) || vectors[i].Id == "" {
+			return nil, fmt.Errorf("memory with ID '%s' not found", id)
+		}
+		if len(vectors[i].Vector) == 0 {
+			return nil, fmt.Errorf("memory with ID '%s' has no stored vector", id)
+		}
+	}
+
+	score, err := cosineSimilarity(vectors[0].Vector, vectors[1].Vector)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing memories: %v", err)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Similarity between '%s' and '%s': %.4f (%s)", idA, idB, score, similarityLabel(score))), nil
+}
+
+// PurgeConfirmation is the confirm value purge-all-memories and
+// clear-all-memories require.
+const PurgeConfirmation = "yes-delete-everything"
+
+// purgePreviewIDs caps how many IDs a purge-all-memories dry run lists.
+const purgePreviewIDs = 20
+
+func (s *Service) purgeAllMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	dryRun, _ := args["dry_run"].(bool)
+	if !dryRun {
+		return s.resetNamespace(ctx, args, "purge", "Purged")
+	}
+
+	ns, err := parseNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.countNamespace(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	scoped, err := s.inNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return scoped.previewPurge(ctx, ns, count)
+}
+
+func (s *Service) clearAllMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.resetNamespace(ctx, request.GetArguments(), "clear", "Cleared")
+}
+
+// resetNamespace resets the namespace named by args once the caller has
+// confirmed it and holds an admin token, reporting how many memories it
+// deleted. verb and done name the action in the refusal and the result.
+func (s *Service) resetNamespace(ctx context.Context, args map[string]any, verb, done string) (*mcp.CallToolResult, error) {
+	confirm, _ := args["confirm"].(string)
+	if confirm != PurgeConfirmation {
+		return nil, fmt.Errorf("refusing to %s memories: set 'confirm' to '%s' to delete everything", verb, PurgeConfirmation)
+	}
+	// Without auth every caller is anonymous, so the reset is refused
+	// outright rather than left open to anyone who can reach the server.
+	if !auth.Admin(ctx) {
+		return nil, fmt.Errorf("refusing to %s memories: it needs a bearer token with the admin role, so it is unavailable without auth", verb)
+	}
+
+	ns, err := parseNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.countNamespace(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	index := s.index
+	if ns != "" {
+		index = index.Namespace(ns)
+	}
+	if err := index.Reset(ctx); err != nil {
+		return nil, fmt.Errorf("error deleting memories: %v", err)
+	}
+
+	if ns != "" {
+		return mcp.NewToolResultText(fmt.Sprintf("%s %d memories from namespace '%s'", done, count, ns)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s %d memories", done, count)), nil
+}
+
+// countNamespace returns how many memories the namespace ns holds.
+func (s *Service) countNamespace(ctx context.Context, ns string) (int, error) {
+	// Info covers the whole index, with a count per namespace. A namespace
+	// missing from it holds nothing, though the default one falls back to
+	// the total for indexes that report no namespaces.
+	info, err := s.index.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error counting memories: %v", err)
+	}
+	if nsInfo, ok := info.Namespaces[ns]; ok {
+		return nsInfo.VectorCount, nil
+	}
+	if ns == "" {
+		return info.VectorCount, nil
+	}
+	return 0, nil
+}
+
+// previewPurge describes what purging the namespace ns of s would delete:
+// count memories, of which the first few IDs are listed.
+func (s *Service) previewPurge(ctx context.Context, ns string, count int) (*mcp.CallToolResult, error) {
+	vectors, more, err := s.rangeAll(ctx, purgePreviewIDs, vector.Range{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	result := fmt.Sprintf("Dry run: would purge %d memories", count)
+	if ns != "" {
+		result += fmt.Sprintf(" from namespace '%s'", ns)
+	}
+	if len(vectors) == 0 {
+		return mcp.NewToolResultText(result), nil
+	}
+
+	ids := make([]string, len(vectors))
+	for i, v := range vectors {
+		ids[i] = v.Id
+	}
+	if more {
+		result += fmt.Sprintf("\nFirst %d IDs: ", len(ids))
+	} else {
+		result += "\nIDs: "
+	}
+	result += strings.Join(ids, ", ")
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// managedMetaKeys are the metadata keys the memory tools maintain
+// themselves. They are hidden when metadata is shown, and callers may only
+// set created_at, which counts as an explicit creation time.
+var managedMetaKeys = map[string]bool{
+	metaContentHash:  true,
+	metaCreatedAt:    true,
+	metaUpdatedAt:    true,
+	metaExpiresAt:    true,
+	metaParentID:     true,
+	metaChunk:        true,
+	metaChunks:       true,
+	metaChunkOverlap: true,
+}
+
+// parseMetadata reads the optional metadata argument, which may be a JSON
+// object or a string holding one.
+func parseMetadata(arg any) (map[string]any, error) {
+	switch v := arg.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return checkMetadataKeys(v)
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		var metadata map[string]any
+		if err := json.Unmarshal([]byte(v), &metadata); err != nil {
+			return nil, fmt.Errorf("argument 'metadata' must be a JSON object: %v", err)
+		}
+		if metadata == nil {
+			return nil, fmt.Errorf("argument 'metadata' must be a JSON object, got null")
+		}
+		return checkMetadataKeys(metadata)
+	default:
+		return nil, fmt.Errorf("argument 'metadata' must be a JSON object, got %T", arg)
+	}
+}
+
+func checkMetadataKeys(metadata map[string]any) (map[string]any, error) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if managedMetaKeys[key] && key != metaCreatedAt {
+			return nil, fmt.Errorf("metadata key '%s' is reserved", key)
+		}
+	}
+	return metadata, nil
+}
+
+// formatMetadata renders the caller-supplied part of metadata as JSON, or
+// returns "" when there is none.
+func formatMetadata(metadata map[string]any) string {
+	shown := maps.Clone(metadata)
+	maps.DeleteFunc(shown, func(key string, _ any) bool {
+		return managedMetaKeys[key]
+	})
+	if len(shown) == 0 {
+		return ""
+	}
+
+	out, err := json.Marshal(shown)
+	if err != nil {
+		return fmt.Sprint(shown)
+	}
+	return string(out)
+}
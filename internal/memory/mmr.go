@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"github.com/upstash/vector-go"
+)
+
+// mmrPoolFactor is how many times top_k candidates a diversity search
+// retrieves, giving the reranking room to skip near-duplicates.
+const mmrPoolFactor = 2
+
+// rerankMMR picks up to k of scores by Maximal Marginal Relevance. Each step
+// takes the candidate with the highest
+//
+//	lambda*relevance - (1-lambda)*(similarity to the closest one picked)
+//
+// so lambda 1 is plain relevance order and lower values favor results
+// unlike those already chosen. Similarity is the cosine of the stored
+// vectors; a candidate without a usable vector counts as unlike the rest.
+func rerankMMR(scores []vector.VectorScore, relevance func(vector.VectorScore) float64, k int, lambda float64) []vector.VectorScore {
+	remaining := append([]vector.VectorScore(nil), scores...)
+	picked := make([]vector.VectorScore, 0, min(k, len(scores)))
+
+	for len(picked) < k && len(remaining) > 0 {
+		best, bestValue := 0, 0.0
+		for i, candidate := range remaining {
+			redundancy := 0.0
+			for _, p := range picked {
+				if sim, err := cosineSimilarity(candidate.Vector, p.Vector); err == nil && sim > redundancy {
+					redundancy = sim
+				}
+			}
+			value := lambda*relevance(candidate) - (1-lambda)*redundancy
+			if i == 0 || value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		picked = append(picked, remaining[best])
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return picked
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/upstash/vector-go"
+)
+
+// parseNamespace reads the optional namespace argument. The empty name,
+// also used when the argument is absent, is the default namespace. A caller
+// whose bearer token is confined to a namespace gets that one, and naming
+// any other is an error.
+func parseNamespace(ctx context.Context, args map[string]any) (string, error) {
+	ns := ""
+	arg, exists := args["namespace"]
+	if exists && arg != nil {
+		var ok bool
+		if ns, ok = arg.(string); !ok {
+			return "", fmt.Errorf("argument 'namespace' must be a string")
+		}
+	}
+
+	bound, confined := auth.Namespace(ctx)
+	if !confined {
+		return ns, nil
+	}
+	if exists && arg != nil && ns != bound {
+		return "", fmt.Errorf("namespace '%s' is not accessible with this token", ns)
+	}
+	return bound, nil
+}
+
+// inNamespace returns a copy of s whose index is confined to the namespace
+// named by the request arguments, or s itself for the default namespace.
+func (s *Service) inNamespace(ctx context.Context, args map[string]any) (*Service, error) {
+	ns, err := parseNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if ns == "" {
+		return s, nil
+	}
+	scoped := *s
+	scoped.index = s.index.Namespace(ns)
+	return &scoped, nil
+}
+
+// callerIndex confines every call made through it to the namespace the
+// caller's bearer token is mapped to, so the tools without a namespace
+// argument cannot reach the default namespace with a confined token.
+type callerIndex struct {
+	Index
+}
+
+func (c callerIndex) scope(ctx context.Context) Index {
+	if ns, confined := auth.Namespace(ctx); confined {
+		return c.Index.Namespace(ns)
+	}
+	return c.Index
+}
+
+func (c callerIndex) UpsertData(ctx context.Context, u vector.UpsertData) error {
+	return c.scope(ctx).UpsertData(ctx, u)
+}
+
+func (c callerIndex) UpsertDataMany(ctx context.Context, u []vector.UpsertData) error {
+	return c.scope(ctx).UpsertDataMany(ctx, u)
+}
+
+func (c callerIndex) Update(ctx context.Context, u vector.Update) (bool, error) {
+	return c.scope(ctx).Update(ctx, u)
+}
+
+func (c callerIndex) Delete(ctx context.Context, id string) (bool, error) {
+	return c.scope(ctx).Delete(ctx, id)
+}
+
+func (c callerIndex) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	return c.scope(ctx).DeleteMany(ctx, ids)
+}
+
+func (c callerIndex) QueryData(ctx context.Context, q vector.QueryData) ([]vector.VectorScore, error) {
+	return c.scope(ctx).QueryData(ctx, q)
+}
+
+func (c callerIndex) Fetch(ctx context.Context, f vector.Fetch) ([]vector.Vector, error) {
+	return c.scope(ctx).Fetch(ctx, f)
+}
+
+func (c callerIndex) Range(ctx context.Context, r vector.Range) (vector.RangeVectors, error) {
+	return c.scope(ctx).Range(ctx, r)
+}
+
+func (c callerIndex) Reset(ctx context.Context) error {
+	return c.scope(ctx).Reset(ctx)
+}
+
+// Namespace returns the namespace ns itself: parseNamespace has already
+// checked it against the caller's token.
+func (c callerIndex) Namespace(ns string) Index {
+	return c.Index.Namespace(ns)
+}
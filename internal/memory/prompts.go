@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/upstash/vector-go"
+)
+
+// qaContextSize is how many memories memory-qa embeds by default.
+const qaContextSize = 5
+
+// Prompts returns the memory prompts ready to be registered on an MCP
+// server.
+func (s *Service) Prompts() []server.ServerPrompt {
+	memoryQA := mcp.NewPrompt("memory-qa",
+		mcp.WithPromptDescription("Answer a question using the stored memories most relevant to it as context"),
+		mcp.WithArgument("question",
+			mcp.RequiredArgument(),
+			mcp.ArgumentDescription("The question to answer"),
+		),
+		mcp.WithArgument("top_k",
+			mcp.ArgumentDescription(fmt.Sprintf("How many memories to include as context (default %d)", qaContextSize)),
+		),
+	)
+
+	return []server.ServerPrompt{
+		{Prompt: memoryQA, Handler: s.memoryQA},
+	}
+}
+
+func (s *Service) memoryQA(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+
+	question := strings.TrimSpace(args["question"])
+	if question == "" {
+		return nil, fmt.Errorf("argument 'question' is missing or empty")
+	}
+
+	topK := qaContextSize
+	if raw, exists := args["top_k"]; exists && raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("argument 'top_k' must be a positive integer, got '%s'", raw)
+		}
+		topK = n
+	}
+
+	scores, err := s.index.QueryData(ctx, vector.QueryData{
+		Data:            question,
+		TopK:            topK,
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching memories: %v", err)
+	}
+
+	var expired []string
+	scores = slices.DeleteFunc(scores, func(score vector.VectorScore) bool {
+		if s.expired(score.Metadata) {
+			expired = append(expired, score.Id)
+			return true
+		}
+		return false
+	})
+	deleteExpired(ctx, s.index, expired...)
+
+	var b strings.Builder
+	b.WriteString("Answer the question below using the memories provided as context. If they do not contain the answer, say so instead of guessing.\n\n")
+	b.WriteString("Memories:\n")
+	if len(scores) == 0 {
+		b.WriteString("(no stored memories are relevant to this question)\n")
+	}
+	for i, score := range scores {
+		fmt.Fprintf(&b, "%d. [%s] %s\n", i+1, score.Id, score.Data)
+	}
+	fmt.Fprintf(&b, "\nQuestion: %s", question)
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Question answered from %d memories", len(scores)),
+		[]mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(b.String())),
+		},
+	), nil
+}
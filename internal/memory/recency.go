@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/upstash/vector-go"
+)
+
+// recencyRanks blends the similarity of each result with how recently it
+// was created, keyed by ID: weight 0 is pure similarity and 1 pure recency.
+// Recency is relative to the results themselves, from 0 for the oldest
+// created_at to 1 for the newest. Results without a created_at keep their
+// similarity.
+func recencyRanks(scores []vector.VectorScore, weight float64) map[string]float64 {
+	created := make(map[string]time.Time)
+	var oldest, newest time.Time
+	for _, score := range scores {
+		raw, ok := score.Metadata[metaCreatedAt].(string)
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			continue
+		}
+		created[score.Id] = t
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+		if t.After(newest) {
+			newest = t
+		}
+	}
+
+	span := newest.Sub(oldest)
+	ranks := make(map[string]float64, len(scores))
+	for _, score := range scores {
+		similarity := float64(score.Score)
+		t, ok := created[score.Id]
+		if !ok {
+			ranks[score.Id] = similarity
+			continue
+		}
+		recency := 1.0
+		if span > 0 {
+			recency = float64(t.Sub(oldest)) / float64(span)
+		}
+		ranks[score.Id] = (1-weight)*similarity + weight*recency
+	}
+	return ranks
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+type recentMemory struct {
+	id      string
+	created time.Time
+	content string
+}
+
+func (s *Service) recentMemories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	limit := 10
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok || l < 1 {
+			return nil, fmt.Errorf("argument 'limit' must be a positive number")
+		}
+		limit = int(l)
+	}
+
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{
+		IncludeMetadata: true,
+		IncludeData:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	var memories []recentMemory
+	seen := make(map[string]bool)
+	for _, v := range vectors {
+		if s.expired(v.Metadata) {
+			continue
+		}
+		// Chunks share the created_at of the memory they belong to.
+		id := v.Id
+		if parent, ok := v.Metadata[metaParentID].(string); ok && parent != "" {
+			id = parent
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		m := recentMemory{id: id, content: v.Data}
+		if raw, ok := v.Metadata[metaCreatedAt].(string); ok {
+			// An unparseable created_at is treated like a missing one.
+			m.created, _ = time.Parse(time.RFC3339, raw)
+		}
+		memories = append(memories, m)
+	}
+
+	if len(memories) == 0 {
+		return mcp.NewToolResultText("No memories stored"), nil
+	}
+
+	sort.Slice(memories, func(i, j int) bool {
+		a, b := memories[i], memories[j]
+		if a.created.IsZero() != b.created.IsZero() {
+			// Legacy memories without created_at go last.
+			return !a.created.IsZero()
+		}
+		if !a.created.Equal(b.created) {
+			return a.created.After(b.created)
+		}
+		return a.id < b.id
+	})
+	if len(memories) > limit {
+		memories = memories[:limit]
+	}
+
+	result := fmt.Sprintf("Listing the %d most recent memories:\n", len(memories))
+	for i, m := range memories {
+		created := "(missing)"
+		if !m.created.IsZero() {
+			created = m.created.UTC().Format(time.RFC3339)
+		}
+		result += fmt.Sprintf("%d. ID: %s, created_at: %s, Content: %s\n", i+1, m.id, created, preview(m.content, previewRunes))
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+func (s *Service) renameMemory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	oldID, ok := args["old_id"].(string)
+	if !ok || strings.TrimSpace(oldID) == "" {
+		return nil, fmt.Errorf("argument 'old_id' is missing or empty")
+	}
+	newID, ok := args["new_id"].(string)
+	if !ok || strings.TrimSpace(newID) == "" {
+		return nil, fmt.Errorf("argument 'new_id' is missing or empty")
+	}
+	if oldID == newID {
+		return nil, fmt.Errorf("arguments 'old_id' and 'new_id' are the same")
+	}
+	overwrite, _ := args["overwrite"].(bool)
+
+	stored, err := s.fetchLive(ctx, oldID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+	if stored == nil {
+		if _, _, chunked, err := s.fetchChunked(ctx, oldID); err == nil && chunked {
+			return nil, fmt.Errorf("memory with ID '%s' is stored in chunks, which rename-memory does not support", oldID)
+		}
+		return nil, fmt.Errorf("memory with ID '%s' not found", oldID)
+	}
+
+	existing, err := s.fetchLive(ctx, newID)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+	if existing != nil && !overwrite {
+		return nil, fmt.Errorf("memory with ID '%s' already exists; set 'overwrite' to replace it", newID)
+	}
+	// Overwriting would leave the old chunks behind under the new ID.
+	if _, _, chunked, err := s.fetchChunked(ctx, newID); err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	} else if chunked {
+		return nil, fmt.Errorf("memory with ID '%s' is stored in chunks and cannot be replaced by rename-memory", newID)
+	}
+
+	// The copy is written before the original is deleted, so a failure in
+	// between leaves the memory under both IDs rather than under neither.
+	if err := s.index.UpsertData(ctx, vector.UpsertData{
+		Id:       newID,
+		Data:     stored.Data,
+		Metadata: stored.Metadata,
+	}); err != nil {
+		return nil, fmt.Errorf("error storing memory under '%s': %v", newID, err)
+	}
+	if _, err := s.index.Delete(ctx, oldID); err != nil {
+		return nil, fmt.Errorf("memory copied to '%s' but deleting '%s' failed: %v", newID, oldID, err)
+	}
+
+	result := fmt.Sprintf("Renamed memory '%s' to '%s'", oldID, newID)
+	if existing != nil {
+		result += ", replacing the memory that had that ID"
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// fetchLive returns the unexpired single-record memory stored under id, or
+// nil when there is none.
+func (s *Service) fetchLive(ctx context.Context, id string) (*vector.Vector, error) {
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{id},
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 || vectors[0].Id != id || s.expired(vectors[0].Metadata) {
+		return nil, nil
+	}
+	return &vectors[0], nil
+}
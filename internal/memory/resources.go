@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/upstash/vector-go"
+)
+
+// resourceScheme prefixes the URI of every memory exposed as a resource;
+// the rest of the URI is the path-escaped memory ID.
+const resourceScheme = "memory://"
+
+// ResourceRegistry is the part of an MCP server that SyncResources keeps
+// in step with the store. *server.MCPServer satisfies it.
+type ResourceRegistry interface {
+	AddResources(resources ...server.ServerResource)
+	RemoveResource(uri string)
+}
+
+// resourceSet remembers which memory resources were last registered, so a
+// sync can remove those of memories deleted since.
+type resourceSet struct {
+	mu   sync.Mutex
+	uris map[string]bool
+}
+
+func resourceURI(id string) string {
+	return resourceScheme + url.PathEscape(id)
+}
+
+// ResourceTemplate returns the memory://{id} template, which reads any
+// stored memory by ID whether or not it has been listed yet.
+func (s *Service) ResourceTemplate() (mcp.ResourceTemplate, server.ResourceTemplateHandlerFunc) {
+	template := mcp.NewResourceTemplate(resourceScheme+"{id}", "memory",
+		mcp.WithTemplateDescription("A stored memory, read by its ID"),
+		mcp.WithTemplateMIMEType("text/plain"),
+	)
+	return template, s.readResource
+}
+
+// Resources returns one memory://{id} resource for every memory currently
+// stored in the default namespace, chunked memories listed once under
+// their own ID.
+func (s *Service) Resources(ctx context.Context) ([]server.ServerResource, error) {
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{IncludeMetadata: true})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	var resources []server.ServerResource
+	seen := make(map[string]bool)
+	for _, v := range vectors {
+		if s.expired(v.Metadata) {
+			continue
+		}
+		id := v.Id
+		if parent, ok := v.Metadata[metaParentID].(string); ok && parent != "" {
+			id = parent
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		resources = append(resources, server.ServerResource{
+			Resource: mcp.NewResource(resourceURI(id), id, mcp.WithMIMEType("text/plain")),
+			Handler:  s.readResource,
+		})
+	}
+	return resources, nil
+}
+
+// SyncResources registers a resource on registry for every memory stored
+// and removes the ones registered by an earlier sync whose memory is gone,
+// so that listing resources reflects the store.
+func (s *Service) SyncResources(ctx context.Context, registry ResourceRegistry) error {
+	resources, err := s.Resources(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.listed.mu.Lock()
+	defer s.listed.mu.Unlock()
+
+	current := make(map[string]bool, len(resources))
+	var added []server.ServerResource
+	for _, r := range resources {
+		current[r.Resource.URI] = true
+		if !s.listed.uris[r.Resource.URI] {
+			added = append(added, r)
+		}
+	}
+	if len(added) > 0 {
+		registry.AddResources(added...)
+	}
+	for uri := range s.listed.uris {
+		if !current[uri] {
+			registry.RemoveResource(uri)
+		}
+	}
+	s.listed.uris = current
+	return nil
+}
+
+func (s *Service) readResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	escaped, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return nil, fmt.Errorf("resource URI '%s' does not start with %s", uri, resourceScheme)
+	}
+	id, err := url.PathUnescape(escaped)
+	if err != nil || id == "" {
+		return nil, fmt.Errorf("resource URI '%s' does not name a memory", uri)
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{id},
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+	if len(vectors) == 1 && vectors[0].Id == id && s.expired(vectors[0].Metadata) {
+		deleteExpired(ctx, s.index, id)
+		vectors = nil
+	}
+
+	var content string
+	if len(vectors) > 0 && vectors[0].Id == id {
+		content = vectors[0].Data
+	} else {
+		var found bool
+		content, _, found, err = s.fetchChunked(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving memory: %v", err)
+		}
+		if !found {
+			return nil, fmt.Errorf("memory with ID '%s' not found", id)
+		}
+	}
+
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "text/plain",
+		Text:     content,
+	}}, nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/upstash/vector-go"
+)
+
+// retryIndex is an Index that retries every call failing with a transient
+// error according to policy.
+type retryIndex struct {
+	index  Index
+	policy retry.Policy
+}
+
+// WithRetry returns index with every call retried on transient errors
+// according to policy. Writes are retried too, which is safe because
+// upserts, updates and deletes give the same result when repeated.
+func WithRetry(index Index, policy retry.Policy) Index {
+	return retryIndex{index: index, policy: policy}
+}
+
+func (r retryIndex) UpsertData(ctx context.Context, u vector.UpsertData) error {
+	return r.policy.Do(ctx, func() error { return r.index.UpsertData(ctx, u) })
+}
+
+func (r retryIndex) UpsertDataMany(ctx context.Context, u []vector.UpsertData) error {
+	return r.policy.Do(ctx, func() error { return r.index.UpsertDataMany(ctx, u) })
+}
+
+func (r retryIndex) Update(ctx context.Context, u vector.Update) (bool, error) {
+	return retry.Value(ctx, r.policy, func() (bool, error) { return r.index.Update(ctx, u) })
+}
+
+func (r retryIndex) Delete(ctx context.Context, id string) (bool, error) {
+	return retry.Value(ctx, r.policy, func() (bool, error) { return r.index.Delete(ctx, id) })
+}
+
+func (r retryIndex) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	return retry.Value(ctx, r.policy, func() (int, error) { return r.index.DeleteMany(ctx, ids) })
+}
+
+func (r retryIndex) QueryData(ctx context.Context, q vector.QueryData) ([]vector.VectorScore, error) {
+	return retry.Value(ctx, r.policy, func() ([]vector.VectorScore, error) { return r.index.QueryData(ctx, q) })
+}
+
+func (r retryIndex) Fetch(ctx context.Context, f vector.Fetch) ([]vector.Vector, error) {
+	return retry.Value(ctx, r.policy, func() ([]vector.Vector, error) { return r.index.Fetch(ctx, f) })
+}
+
+func (r retryIndex) Range(ctx context.Context, rg vector.Range) (vector.RangeVectors, error) {
+	return retry.Value(ctx, r.policy, func() (vector.RangeVectors, error) { return r.index.Range(ctx, rg) })
+}
+
+func (r retryIndex) Reset(ctx context.Context) error {
+	return r.policy.Do(ctx, func() error { return r.index.Reset(ctx) })
+}
+
+func (r retryIndex) Info(ctx context.Context) (vector.IndexInfo, error) {
+	return retry.Value(ctx, r.policy, func() (vector.IndexInfo, error) { return r.index.Info(ctx) })
+}
+
+func (r retryIndex) Namespace(ns string) Index {
+	return retryIndex{index: r.index.Namespace(ns), policy: r.policy}
+}
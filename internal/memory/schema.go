@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// MetadataSchema is a JSON schema that the caller-supplied metadata of
+// every memory must satisfy.
+type MetadataSchema struct {
+	schema *jsonschema.Schema
+}
+
+// LoadMetadataSchema compiles the JSON schema in the file at path.
+func LoadMetadataSchema(path string) (*MetadataSchema, error) {
+	schema, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading metadata schema %s: %v", path, err)
+	}
+	return &MetadataSchema{schema: schema}, nil
+}
+
+// Validate reports how metadata fails the schema, or nil when it conforms.
+// Missing metadata is validated as an empty object.
+func (m *MetadataSchema) Validate(metadata map[string]any) error {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	// A JSON round trip turns the values into the types the validator
+	// expects, numbers in particular.
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("metadata cannot be encoded as JSON: %v", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("metadata cannot be encoded as JSON: %v", err)
+	}
+
+	err = m.schema.Validate(doc)
+	var verr *jsonschema.ValidationError
+	if errors.As(err, &verr) {
+		return fmt.Errorf("metadata does not match the schema: %s", strings.Join(schemaViolations(verr), "; "))
+	}
+	return err
+}
+
+// schemaViolations lists the failed constraints of a validation error, one
+// per line of its report. The first line, which names the schema file, is
+// left out.
+func schemaViolations(verr *jsonschema.ValidationError) []string {
+	var out []string
+	for _, line := range strings.Split(verr.Error(), "\n")[1:] {
+		if line = strings.TrimPrefix(strings.TrimSpace(line), "- "); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// validateMetadata checks metadata against the configured schema, if any.
+func (s *Service) validateMetadata(metadata map[string]any) error {
+	if s.cfg.MetadataSchema == nil {
+		return nil
+	}
+	return s.cfg.MetadataSchema.Validate(metadata)
+}
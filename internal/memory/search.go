@@ -0,0 +1,419 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// searchParams are the inputs of a search, kept per session for refine-search.
+type searchParams struct {
+	query string
+	topK  int
+	// ascending lists the least similar results first.
+	ascending bool
+	// explain annotates each result with why it matched.
+	explain bool
+	// filter restricts results to memories whose metadata matches this
+	// Upstash filter expression, when not empty.
+	filter string
+	// tags keeps only results whose metadata tags hold all of these, or
+	// with tagsAny at least one of them.
+	tags    []string
+	tagsAny bool
+	// namespace is the namespace searched, empty for the default one.
+	namespace string
+	// minScore drops results whose similarity is below it.
+	minScore float64
+	// json returns the results as a JSON array instead of text.
+	json bool
+	// includeVectors adds each result's embedding vector to JSON output.
+	includeVectors bool
+	// diversity reranks the results by Maximal Marginal Relevance, with
+	// mmrLambda weighing relevance against novelty.
+	diversity bool
+	mmrLambda float64
+	// recencyBoost re-ranks results by blending similarity with recency,
+	// from 0 (pure similarity) to 1 (pure recency).
+	recencyBoost float64
+	// previewChars truncates each result's content to this many runes,
+	// when not zero.
+	previewChars int
+	// template renders each text result in place of the default format,
+	// when not nil.
+	template *template.Template
+}
+
+func (s *Service) searchMemory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	query, ok := args["query"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'query' is missing or not a string")
+	}
+
+	namespace, err := parseNamespace(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	params := searchParams{
+		query:     query,
+		topK:      s.cfg.DefaultTopK,
+		namespace: namespace,
+		mmrLambda: s.cfg.MMRLambda,
+	}
+	if err := s.parseSearchOptions(args, &params); err != nil {
+		return nil, err
+	}
+	s.searches.remember(sessionID(ctx), params, s.cfg.Now())
+
+	result, err := s.search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+func (s *Service) refineSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	params, ok := s.searches.recall(sessionID(ctx), s.cfg.Now())
+	if !ok {
+		return nil, fmt.Errorf("no previous search to refine in this session; run search-memory first")
+	}
+
+	if err := s.parseSearchOptions(args, &params); err != nil {
+		return nil, err
+	}
+	s.searches.remember(sessionID(ctx), params, s.cfg.Now())
+
+	result, err := s.search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if params.json {
+		return mcp.NewToolResultText(result), nil
+	}
+
+	header := fmt.Sprintf("Refined search for '%s' (top_k: %d)\n", params.query, params.topK)
+	return mcp.NewToolResultText(header + result), nil
+}
+
+// parseSearchOptions applies the optional search arguments present in args
+// to params, leaving the others at their current values.
+func (s *Service) parseSearchOptions(args map[string]any, params *searchParams) error {
+	topK, err := s.parseTopK(args, params.topK)
+	if err != nil {
+		return err
+	}
+	params.topK = topK
+
+	if orderArg, exists := args["order"]; exists {
+		order, ok := orderArg.(string)
+		if !ok {
+			return fmt.Errorf("argument 'order' must be a string")
+		}
+
+		switch order {
+		case "desc":
+			params.ascending = false
+		case "asc":
+			params.ascending = true
+		default:
+			return fmt.Errorf("argument 'order' must be 'asc' or 'desc', got '%s'", order)
+		}
+	}
+
+	if explain, ok := args["explain"].(bool); ok {
+		params.explain = explain
+	}
+
+	if includeVectors, ok := args["include_vectors"].(bool); ok {
+		params.includeVectors = includeVectors
+	}
+
+	if diversity, ok := args["diversity"].(bool); ok {
+		params.diversity = diversity
+	}
+
+	if lambdaArg, exists := args["diversity_lambda"]; exists {
+		lambda, ok := lambdaArg.(float64)
+		if !ok || lambda < 0 || lambda > 1 {
+			return fmt.Errorf("argument 'diversity_lambda' must be a number between 0 and 1")
+		}
+		params.mmrLambda = lambda
+	}
+
+	if filterArg, exists := args["filter"]; exists {
+		filter, ok := filterArg.(string)
+		if !ok {
+			return fmt.Errorf("argument 'filter' must be a string")
+		}
+		if strings.TrimSpace(filter) != "" {
+			if err := validateFilter(filter); err != nil {
+				return fmt.Errorf("invalid filter: %v", err)
+			}
+		}
+		params.filter = filter
+	}
+
+	if tagsArg, exists := args["tags"]; exists {
+		tags, err := parseTags(tagsArg)
+		if err != nil {
+			return err
+		}
+		params.tags = tags
+	}
+
+	if tagsAny, ok := args["tags_any"].(bool); ok {
+		params.tagsAny = tagsAny
+	}
+
+	if minScoreArg, exists := args["min_score"]; exists {
+		minScore, ok := minScoreArg.(float64)
+		if !ok {
+			return fmt.Errorf("argument 'min_score' must be a number")
+		}
+		if minScore < 0 || minScore > 1 {
+			return fmt.Errorf("argument 'min_score' must be between 0 and 1, got %g", minScore)
+		}
+		params.minScore = minScore
+	}
+
+	if boostArg, exists := args["recency_boost"]; exists {
+		boost, ok := boostArg.(float64)
+		if !ok {
+			return fmt.Errorf("argument 'recency_boost' must be a number")
+		}
+		if boost < 0 || boost > 1 {
+			return fmt.Errorf("argument 'recency_boost' must be between 0 and 1, got %g", boost)
+		}
+		params.recencyBoost = boost
+	}
+
+	if previewArg, exists := args["preview_chars"]; exists {
+		chars, ok := previewArg.(float64)
+		if !ok || chars < 0 || chars != math.Trunc(chars) {
+			return fmt.Errorf("argument 'preview_chars' must be a non-negative integer")
+		}
+		params.previewChars = int(chars)
+	}
+
+	if templateArg, exists := args["template"]; exists {
+		tmpl, err := parseTemplate(templateArg)
+		if err != nil {
+			return err
+		}
+		params.template = tmpl
+	}
+
+	asJSON, err := parseJSONFormat(args, params.json)
+	if err != nil {
+		return err
+	}
+	params.json = asJSON
+
+	return nil
+}
+
+// search runs params against the index and formats the matches as text.
+func (s *Service) search(ctx context.Context, params searchParams) (string, error) {
+	query := vector.QueryData{
+		Data:            params.query,
+		TopK:            params.topK,
+		IncludeData:     true,
+		IncludeMetadata: true,
+		// Text output never shows vectors, so they are only fetched for JSON
+		// or to compare results with each other.
+		IncludeVectors: params.includeVectors && params.json || params.diversity,
+	}
+	if params.diversity {
+		query.TopK = params.topK * mmrPoolFactor
+	}
+	if strings.TrimSpace(params.filter) != "" {
+		query.Filter = params.filter
+	}
+
+	index := s.index
+	if params.namespace != "" {
+		index = index.Namespace(params.namespace)
+	}
+	scores, err := index.QueryData(ctx, query)
+
+	if err != nil {
+		return "", fmt.Errorf("error searching memories: %v", err)
+	}
+
+	var expired []string
+	scores = slices.DeleteFunc(scores, func(score vector.VectorScore) bool {
+		if s.expired(score.Metadata) {
+			expired = append(expired, score.Id)
+			return true
+		}
+		return false
+	})
+	deleteExpired(ctx, index, expired...)
+
+	// The index returns the top_k best matches, so dropping the weak ones
+	// afterwards still leaves at most top_k results.
+	// Scores are compared in the index's float32 precision, so a score
+	// equal to the cutoff is kept.
+	if params.minScore > 0 {
+		scores = slices.DeleteFunc(scores, func(score vector.VectorScore) bool {
+			return score.Score < float32(params.minScore)
+		})
+	}
+	if len(params.tags) > 0 {
+		scores = slices.DeleteFunc(scores, func(score vector.VectorScore) bool {
+			return !hasTags(score.Metadata, params.tags, params.tagsAny)
+		})
+	}
+
+	// rank orders the results: their similarity, or with a recency boost
+	// the blend of similarity and recency.
+	var ranks map[string]float64
+	rank := func(score vector.VectorScore) float64 { return float64(score.Score) }
+	if params.recencyBoost > 0 {
+		ranks = recencyRanks(scores, params.recencyBoost)
+		rank = func(score vector.VectorScore) float64 { return ranks[score.Id] }
+		sort.SliceStable(scores, func(i, j int) bool {
+			return rank(scores[i]) > rank(scores[j])
+		})
+	}
+
+	if params.diversity {
+		scores = rerankMMR(scores, rank, params.topK, params.mmrLambda)
+	}
+
+	if params.ascending {
+		sort.SliceStable(scores, func(i, j int) bool {
+			return rank(scores[i]) < rank(scores[j])
+		})
+	}
+
+	content := func(score vector.VectorScore) string {
+		if params.previewChars > 0 {
+			return preview(score.Data, params.previewChars)
+		}
+		return score.Data
+	}
+
+	if params.json {
+		results := make([]MemoryResult, len(scores))
+		for i, score := range scores {
+			results[i] = MemoryResult{
+				ID:       score.Id,
+				Score:    &score.Score,
+				Content:  content(score),
+				Metadata: withoutManaged(score.Metadata),
+			}
+			if params.includeVectors {
+				results[i].Vector = score.Vector
+			}
+			if ranks != nil {
+				boosted := ranks[score.Id]
+				results[i].Boosted = &boosted
+			}
+			if params.explain {
+				results[i].Why = explainMatch(params, score)
+				results[i].Explain = &Explanation{
+					RawScore:      score.Score,
+					Query:         params.query,
+					ContentLength: utf8.RuneCountInString(score.Data),
+				}
+			}
+		}
+		return encodeResults(results)
+	}
+
+	if len(scores) == 0 {
+		return "No memories found matching your query", nil
+	}
+
+	result := fmt.Sprintf("Found %d memories:\n", len(scores))
+	if params.template != nil {
+		for _, score := range scores {
+			rendered, err := renderResult(params.template, score, content(score))
+			if err != nil {
+				return "", err
+			}
+			result += rendered + "\n"
+		}
+		return result, nil
+	}
+	for i, score := range scores {
+		if ranks != nil {
+			result += fmt.Sprintf("%d. ID: %s, Score: %.4f, Boosted: %.4f, Content: %s\n", i+1, score.Id, score.Score, ranks[score.Id], content(score))
+		} else {
+			result += fmt.Sprintf("%d. ID: %s, Score: %.4f, Content: %s\n", i+1, score.Id, score.Score, content(score))
+		}
+		if metadata := formatMetadata(score.Metadata); metadata != "" {
+			result += "   Metadata: " + metadata + "\n"
+		}
+		if params.explain {
+			result += "   Why: " + explainMatch(params, score) + "\n"
+		}
+	}
+
+	return result, nil
+}
+
+// explainMatch describes why score was returned for params using only the
+// score, the constraints applied and the stored text.
+func explainMatch(params searchParams, score vector.VectorScore) string {
+	reasons := []string{
+		fmt.Sprintf("semantic similarity %.4f to '%s'", score.Score, params.query),
+	}
+	if strings.TrimSpace(params.filter) != "" {
+		reasons = append(reasons, fmt.Sprintf("metadata matches filter %s", params.filter))
+	}
+	if len(params.tags) > 0 {
+		mode := "all"
+		if params.tagsAny {
+			mode = "at least one"
+		}
+		reasons = append(reasons, fmt.Sprintf("tagged with %s of %s", mode, strings.Join(params.tags, ", ")))
+	}
+	if strings.TrimSpace(params.filter) == "" && len(params.tags) == 0 {
+		reasons = append(reasons, "no filter or tag constraints applied")
+	}
+	if params.minScore > 0 {
+		reasons = append(reasons, fmt.Sprintf("score at or above min_score %g", params.minScore))
+	}
+
+	if terms := matchedTerms(params.query, score.Data); len(terms) > 0 {
+		reasons = append(reasons, fmt.Sprintf("content contains query terms: %s", strings.Join(terms, ", ")))
+	} else {
+		reasons = append(reasons, "no query terms appear literally in the content")
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// matchedTerms returns the distinct words of query that occur in content,
+// ignoring case.
+func matchedTerms(query, content string) []string {
+	content = strings.ToLower(content)
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		word = strings.Trim(word, ".,;:!?\"'()")
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		if strings.Contains(content, word) {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
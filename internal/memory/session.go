@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// searchSessions remembers the most recent search of each client session so
+// refine-search can re-run it. Entries expire after a period of inactivity.
+type searchSessions struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]lastSearch
+}
+
+type lastSearch struct {
+	params searchParams
+	at     time.Time
+}
+
+func newSearchSessions(ttl time.Duration) *searchSessions {
+	return &searchSessions{
+		ttl:     ttl,
+		entries: make(map[string]lastSearch),
+	}
+}
+
+// remember records params as the latest search of session and drops any
+// entries that have expired.
+func (ss *searchSessions) remember(session string, params searchParams, now time.Time) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for id, entry := range ss.entries {
+		if now.Sub(entry.at) > ss.ttl {
+			delete(ss.entries, id)
+		}
+	}
+	ss.entries[session] = lastSearch{params: params, at: now}
+}
+
+// recall returns the latest search of session unless it has expired.
+func (ss *searchSessions) recall(session string, now time.Time) (searchParams, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	entry, ok := ss.entries[session]
+	if !ok {
+		return searchParams{}, false
+	}
+	if now.Sub(entry.at) > ss.ttl {
+		delete(ss.entries, session)
+		return searchParams{}, false
+	}
+	return entry.params, true
+}
+
+// sessionID identifies the client session of a tool call. Calls made
+// outside a session share the empty ID.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// cosineSimilarity returns the cosine of the angle between a and b.
+func cosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vector dimensions differ: %d vs %d", len(a), len(b))
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0, fmt.Errorf("cannot compare a zero vector")
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// similarityThreshold returns the per-call 'threshold' argument, or the
+// configured SimilarityThreshold when it is absent.
+func (s *Service) similarityThreshold(args map[string]any) (float64, error) {
+	thresholdArg, exists := args["threshold"]
+	if !exists {
+		return s.cfg.SimilarityThreshold, nil
+	}
+
+	threshold, ok := thresholdArg.(float64)
+	if !ok || threshold < -1 || threshold > 1 {
+		return 0, fmt.Errorf("argument 'threshold' must be a number between -1 and 1")
+	}
+	return threshold, nil
+}
+
+// similarityLabel describes a cosine similarity score in words.
+func similarityLabel(score float64) string {
+	switch {
+	case score >= 0.9:
+		return "highly similar"
+	case score >= 0.75:
+		return "similar"
+	case score >= 0.5:
+		return "somewhat similar"
+	default:
+		return "dissimilar"
+	}
+}
+
+// formatVector renders at most limit dimensions of v, noting how many were
+// left out.
+func formatVector(v []float32, limit int) string {
+	if len(v) == 0 {
+		return "Vector: (none stored)"
+	}
+
+	shown := v
+	if limit > 0 && len(v) > limit {
+		shown = v[:limit]
+	}
+
+	parts := make([]string, len(shown))
+	for i, x := range shown {
+		parts[i] = fmt.Sprintf("%.4f", x)
+	}
+
+	out := fmt.Sprintf("Vector (%d dims): [%s", len(v), strings.Join(parts, ", "))
+	if len(shown) < len(v) {
+		out += fmt.Sprintf(", ... %d more", len(v)-len(shown))
+	}
+	return out + "]"
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+// metaTags is the metadata field search-memory's tags argument matches.
+const metaTags = "tags"
+
+// parseTags reads the optional tags argument, an array of non-empty
+// strings. An empty array clears the tag constraint.
+func parseTags(arg any) ([]string, error) {
+	raw, ok := arg.([]any)
+	if !ok {
+		return nil, fmt.Errorf("argument 'tags' must be an array of strings")
+	}
+	tags := make([]string, 0, len(raw))
+	for i, entry := range raw {
+		tag, ok := entry.(string)
+		if !ok || tag == "" {
+			return nil, fmt.Errorf("argument 'tags' must hold non-empty strings, got %v at position %d", entry, i)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// hasTags reports whether the tags field of metadata holds all of tags, or
+// with anyTag at least one of them.
+func hasTags(metadata map[string]any, tags []string, anyTag bool) bool {
+	stored := storedTags(metadata)
+	for _, tag := range tags {
+		found := slices.Contains(stored, tag)
+		if anyTag && found {
+			return true
+		}
+		if !anyTag && !found {
+			return false
+		}
+	}
+	return !anyTag
+}
+
+// storedTags returns the string entries of the tags field of metadata.
+func storedTags(metadata map[string]any) []string {
+	switch v := metadata[metaTags].(type) {
+	case []string:
+		return v
+	case []any:
+		var tags []string
+		for _, entry := range v {
+			if tag, ok := entry.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+// untaggedLabel labels the tag-usage-stats row of memories without tags.
+const untaggedLabel = "(untagged)"
+
+type tagUsage struct {
+	tag   string
+	count int
+	bytes int
+}
+
+func (s *Service) tagUsageStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	vectors, _, err := s.rangeAll(ctx, math.MaxInt, vector.Range{
+		IncludeMetadata: true,
+		IncludeData:     true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing memories: %v", err)
+	}
+
+	// Chunks carry the tags of their memory, which is counted once with
+	// the bytes of all its chunks.
+	type memoryUsage struct {
+		tags  []string
+		bytes int
+	}
+	memories := make(map[string]*memoryUsage)
+	for _, v := range vectors {
+		if s.expired(v.Metadata) {
+			continue
+		}
+		id := v.Id
+		if parent, ok := v.Metadata[metaParentID].(string); ok && parent != "" {
+			id = parent
+		}
+		m := memories[id]
+		if m == nil {
+			m = &memoryUsage{tags: storedTags(v.Metadata)}
+			memories[id] = m
+		}
+		m.bytes += len(v.Data)
+	}
+
+	if len(memories) == 0 {
+		return mcp.NewToolResultText("No memories stored"), nil
+	}
+
+	usage := make(map[string]*tagUsage)
+	untagged := &tagUsage{tag: untaggedLabel}
+	for _, m := range memories {
+		tags := slices.Compact(slices.Sorted(slices.Values(m.tags)))
+		if len(tags) == 0 {
+			untagged.count++
+			untagged.bytes += m.bytes
+			continue
+		}
+		// A memory with several tags counts toward each of them.
+		for _, tag := range tags {
+			u := usage[tag]
+			if u == nil {
+				u = &tagUsage{tag: tag}
+				usage[tag] = u
+			}
+			u.count++
+			u.bytes += m.bytes
+		}
+	}
+
+	sorted := make([]*tagUsage, 0, len(usage)+1)
+	for _, u := range usage {
+		sorted = append(sorted, u)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].tag < sorted[j].tag
+	})
+	if untagged.count > 0 {
+		sorted = append(sorted, untagged)
+	}
+
+	result := fmt.Sprintf("Tag usage across %d memories:\n", len(memories))
+	for _, u := range sorted {
+		result += fmt.Sprintf("- %s: %d memories, %d bytes\n", u.tag, u.count, u.bytes)
+	}
+	return mcp.NewToolResultText(result), nil
+}
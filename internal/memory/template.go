@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/upstash/vector-go"
+)
+
+// parseTemplate reads the template argument, Go text/template syntax that
+// search-memory renders each text result with. An empty template selects
+// the default format and returns nil.
+func parseTemplate(arg any) (*template.Template, error) {
+	text, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'template' must be a string")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("result").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("argument 'template' is not a valid template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// renderResult renders one search result with tmpl, which sees the fields
+// id, score, content and metadata, the last holding only the
+// caller-supplied keys.
+func renderResult(tmpl *template.Template, score vector.VectorScore, content string) (string, error) {
+	var out strings.Builder
+	err := tmpl.Execute(&out, map[string]any{
+		"id":       score.Id,
+		"score":    score.Score,
+		"content":  content,
+		"metadata": withoutManaged(score.Metadata),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering template for memory '%s': %v", score.Id, err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metadata keys recording when a memory was first stored and last written.
+// Both hold RFC 3339 timestamps in UTC.
+const (
+	metaCreatedAt = "created_at"
+	metaUpdatedAt = "updated_at"
+)
+
+// parseCreatedAt validates a caller-supplied created_at argument and
+// normalizes it to UTC.
+func parseCreatedAt(raw string) (string, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return "", fmt.Errorf("argument 'created_at' must be an RFC 3339 timestamp, got '%s'", raw)
+	}
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// stamp sets the timestamps of a memory about to be written into metadata.
+// createdAt, when not empty, is the caller's explicit creation time;
+// otherwise the creation time is kept from stored, the metadata of the
+// existing record, or taken from the clock for a new memory.
+func (s *Service) stamp(metadata, stored map[string]any, createdAt string) {
+	if createdAt != "" {
+		metadata[metaCreatedAt] = createdAt
+	}
+	if !s.cfg.AutoTimestamps {
+		return
+	}
+
+	now := s.cfg.Now().UTC().Format(time.RFC3339)
+	if createdAt == "" {
+		if previous, ok := stored[metaCreatedAt].(string); ok && previous != "" {
+			metadata[metaCreatedAt] = previous
+		} else {
+			metadata[metaCreatedAt] = now
+		}
+	}
+	metadata[metaUpdatedAt] = now
+}
+
+// timestamps returns the created_at and updated_at recorded in metadata,
+// or "" for either that the memory does not carry.
+func timestamps(metadata map[string]any) (created, updated string) {
+	created, _ = metadata[metaCreatedAt].(string)
+	updated, _ = metadata[metaUpdatedAt].(string)
+	return created, updated
+}
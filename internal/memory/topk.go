@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Rounding selects how a fractional top_k is turned into a result count.
+// JSON has no integer type, so top_k always arrives as a float64.
+type Rounding int
+
+const (
+	// Truncate drops the fraction, so 7.9 becomes 7.
+	Truncate Rounding = iota
+	// Round rounds to the nearest integer, halves away from zero, so 7.5
+	// becomes 8.
+	Round
+)
+
+// ParseRounding converts "truncate" or "round" to a Rounding.
+func ParseRounding(s string) (Rounding, error) {
+	switch s {
+	case "", "truncate":
+		return Truncate, nil
+	case "round":
+		return Round, nil
+	default:
+		return Truncate, fmt.Errorf("unknown top_k rounding %q, want truncate or round", s)
+	}
+}
+
+// parseTopK reads the optional top_k argument, returning fallback when it
+// is absent. A fractional value is rounded by the configured policy, or
+// rejected when StrictTopK is set; so is a string that is not an integer,
+// which is otherwise ignored. A value below 1 is always rejected.
+func (s *Service) parseTopK(args map[string]any, fallback int) (int, error) {
+	topK, err := s.readTopK(args, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if topK < 1 {
+		return 0, fmt.Errorf("argument 'top_k' must be at least 1, got %d", topK)
+	}
+	return topK, nil
+}
+
+// readTopK is parseTopK without the bounds check.
+func (s *Service) readTopK(args map[string]any, fallback int) (int, error) {
+	topKArg, exists := args["top_k"]
+	if !exists {
+		return fallback, nil
+	}
+
+	switch v := topKArg.(type) {
+	case float64:
+		if v != math.Trunc(v) && s.cfg.StrictTopK {
+			return 0, fmt.Errorf("argument 'top_k' must be a whole number, got %g", v)
+		}
+		if s.cfg.TopKRounding == Round {
+			return int(math.Round(v)), nil
+		}
+		return int(v), nil
+	case string:
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			if s.cfg.StrictTopK {
+				return 0, fmt.Errorf("argument 'top_k' must be a whole number, got '%s'", v)
+			}
+			return fallback, nil
+		}
+		return parsed, nil
+	default:
+		if s.cfg.StrictTopK {
+			return 0, fmt.Errorf("argument 'top_k' must be a number")
+		}
+		return fallback, nil
+	}
+}
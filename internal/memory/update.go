@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/upstash/vector-go"
+)
+
+func (s *Service) updateMemory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	id, ok := args["id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'id' is missing or not a string")
+	}
+
+	content, hasContent := args["content"].(string)
+	if _, present := args["content"]; present && !hasContent {
+		return nil, fmt.Errorf("argument 'content' must be a string")
+	}
+	if hasContent {
+		if err := s.checkContent(content); err != nil {
+			return nil, err
+		}
+	}
+
+	patch, err := parseMetadata(args["metadata"])
+	if err != nil {
+		return nil, err
+	}
+	if !hasContent && patch == nil {
+		return nil, fmt.Errorf("nothing to update: provide 'content', 'metadata' or both")
+	}
+
+	vectors, err := s.index.Fetch(ctx, vector.Fetch{
+		Ids:             []string{id},
+		IncludeData:     true,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+	if len(vectors) == 0 || vectors[0].Id != id {
+		return nil, fmt.Errorf("memory with ID '%s' not found; use add-to-memory to create it", id)
+	}
+	stored := vectors[0]
+
+	var createdAt string
+	if raw, _ := patch[metaCreatedAt].(string); raw != "" {
+		if createdAt, err = parseCreatedAt(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	// The patch is merged into the caller-supplied keys the way a JSON Merge
+	// Patch would: a null value removes the key, anything else replaces it.
+	previous := withoutManaged(stored.Metadata)
+	user := maps.Clone(previous)
+	for key, value := range patch {
+		if key == metaCreatedAt {
+			continue
+		}
+		if value == nil {
+			delete(user, key)
+		} else {
+			user[key] = value
+		}
+	}
+
+	if patch != nil {
+		if err := s.validateMetadata(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasContent {
+		content = stored.Data
+	}
+
+	var changed []string
+	if content != stored.Data {
+		changed = append(changed, "content")
+	}
+	if contentHash("", user) != contentHash("", previous) || createdAt != "" {
+		changed = append(changed, "metadata")
+	}
+	if len(changed) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No change to memory with ID: %s", id)), nil
+	}
+
+	meta := maps.Clone(user)
+	for key, value := range stored.Metadata {
+		if managedMetaKeys[key] {
+			meta[key] = value
+		}
+	}
+	meta[metaContentHash] = contentHash(content, user)
+	s.stamp(meta, stored.Metadata, createdAt)
+
+	update := vector.Update{
+		Id:                 id,
+		Metadata:           meta,
+		MetadataUpdateMode: vector.MetadataUpdateModeOverwrite,
+	}
+	// Unchanged content is left out so a metadata-only update does not
+	// re-embed the memory.
+	if content != stored.Data {
+		update.Data = content
+	}
+
+	ok, err = s.index.Update(ctx, update)
+	if err != nil {
+		return nil, fmt.Errorf("error updating memory: %v", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("memory with ID '%s' not found; use add-to-memory to create it", id)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully updated %s of memory with ID: %s", strings.Join(changed, " and "), id)), nil
+}
+
+// withoutManaged returns a copy of metadata holding only the
+// caller-supplied keys.
+func withoutManaged(metadata map[string]any) map[string]any {
+	user := maps.Clone(metadata)
+	if user == nil {
+		user = map[string]any{}
+	}
+	maps.DeleteFunc(user, func(key string, _ any) bool {
+		return managedMetaKeys[key]
+	})
+	return user
+}
+
+func (s *Service) updateMemoryMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	id, ok := args["id"].(string)
+	if !ok || strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("argument 'id' is missing or empty")
+	}
+
+	user, err := parseMetadata(args["metadata"])
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("argument 'metadata' is missing")
+	}
+	user = maps.Clone(user)
+
+	var createdAt string
+	if raw, _ := user[metaCreatedAt].(string); raw != "" {
+		if createdAt, err = parseCreatedAt(raw); err != nil {
+			return nil, err
+		}
+	}
+	delete(user, metaCreatedAt)
+	maps.DeleteFunc(user, func(_ string, value any) bool {
+		return value == nil
+	})
+	if err := s.validateMetadata(user); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.fetchLive(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving memory: %v", err)
+	}
+	if stored == nil {
+		if _, _, chunked, err := s.fetchChunked(ctx, id); err == nil && chunked {
+			return nil, fmt.Errorf("memory with ID '%s' is stored in chunks, which update-memory-metadata does not support", id)
+		}
+		return nil, fmt.Errorf("memory with ID '%s' not found", id)
+	}
+
+	meta := user
+	for key, value := range stored.Metadata {
+		if managedMetaKeys[key] {
+			meta[key] = value
+		}
+	}
+	meta[metaContentHash] = contentHash(stored.Data, user)
+	s.stamp(meta, stored.Metadata, createdAt)
+
+	// Only the metadata is sent, so Upstash keeps the stored content and
+	// vector as they are rather than re-embedding the memory.
+	ok, err = s.index.Update(ctx, vector.Update{
+		Id:                 id,
+		Metadata:           meta,
+		MetadataUpdateMode: vector.MetadataUpdateModeOverwrite,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating memory: %v", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("memory with ID '%s' not found", id)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully replaced metadata of memory with ID: %s", id)), nil
+}
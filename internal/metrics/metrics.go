@@ -0,0 +1,78 @@
+// Package metrics publishes Prometheus metrics about tool calls.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/middleware"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics counts tool calls and times their handlers. Each Metrics has its
+// own registry, so several can live in one process.
+type Metrics struct {
+	registry *prometheus.Registry
+	calls    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New returns Metrics with the tool call metrics and the standard Go and
+// process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mcp_tool_calls_total",
+			Help: "Tool calls handled, by tool and outcome (ok or error).",
+		}, []string{"tool", "outcome"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mcp_tool_call_duration_seconds",
+			Help:    "Time spent in tool handlers, by tool.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"tool"}),
+	}
+	m.registry.MustRegister(
+		m.calls,
+		m.latency,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Middleware records every tool call. A call whose handler returns an error
+// or an error result counts as an error.
+func (m *Metrics) Middleware() server.ToolHandlerMiddleware {
+	timing := middleware.Timing(func(tool string, elapsed time.Duration) {
+		m.latency.WithLabelValues(tool).Observe(elapsed.Seconds())
+	})
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		timed := timing(next)
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := timed(ctx, request)
+
+			outcome := "ok"
+			if err != nil || (result != nil && result.IsError) {
+				outcome = "error"
+			}
+			m.calls.WithLabelValues(request.Params.Name, outcome).Inc()
+			return result, err
+		}
+	}
+}
+
+// DefaultPath is where the metrics are served unless METRICS_PATH says
+// otherwise.
+const DefaultPath = "/metrics"
+
+// Register adds GET path to mux, serving the metrics in the Prometheus text
+// format.
+func (m *Metrics) Register(mux *http.ServeMux, path string) {
+	mux.Handle("GET "+path, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,65 @@
+// Package middleware composes tool handler middlewares and ships the
+// generic ones: timing and panic recovery. A middleware has mcp-go's
+// server.ToolHandlerMiddleware type, func(next) handler, so the ones here
+// and in the logging, metrics, ratelimit and timeout packages mix freely.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Chain wraps handler in mws. The first middleware is the outermost, the
+// order server.WithToolHandlerMiddleware applies them in.
+func Chain(handler server.ToolHandlerFunc, mws ...server.ToolHandlerMiddleware) server.ToolHandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// Timing reports how long every tool call took to record, with the name of
+// the tool, once the handler returns.
+func Timing(record func(tool string, elapsed time.Duration)) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			record(request.Params.Name, time.Since(start))
+			return result, err
+		}
+	}
+}
+
+// Recover turns a panic in the handler into an error result, so one bad
+// call fails on its own instead of taking the server and its SSE sessions
+// down. The panic is logged to logger with its stack trace. A panic can
+// only be recovered on the goroutine it happens on, so Recover must be
+// inside any middleware that runs the handler on a goroutine of its own,
+// such as timeout.Middleware.
+func Recover(logger *slog.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.LogAttrs(ctx, slog.LevelError, "tool handler panicked",
+						slog.String("tool", request.Params.Name),
+						slog.String("request_id", logging.RequestID(ctx)),
+						slog.String("panic", fmt.Sprint(r)),
+						slog.String("stack", string(debug.Stack())),
+					)
+					result = mcp.NewToolResultError(fmt.Sprintf("tool '%s' failed with an internal error: %v", request.Params.Name, r))
+					err = nil
+				}
+			}()
+			return next(ctx, request)
+		}
+	}
+}
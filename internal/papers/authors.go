@@ -0,0 +1,143 @@
+package papers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agnivade/levenshtein"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// authorKeyPrefix starts the key of every author set, which holds the
+// titles of the papers the author wrote. Titles may not start with it.
+const authorKeyPrefix = "author:"
+
+// authorKey returns the key of the set for author.
+func authorKey(author string) string {
+	return authorKeyPrefix + normalizeAuthor(author)
+}
+
+// normalizeAuthor lowercases author and collapses its whitespace, so
+// "Jane  Doe" and "jane doe" name the same author.
+func normalizeAuthor(author string) string {
+	return strings.Join(strings.Fields(strings.ToLower(author)), " ")
+}
+
+// parseAuthors reads the optional authors argument, an array of names.
+func parseAuthors(arg any) ([]string, error) {
+	if arg == nil {
+		return nil, nil
+	}
+	raw, ok := arg.([]any)
+	if !ok {
+		return nil, fmt.Errorf("argument 'authors' must be an array of strings")
+	}
+
+	var authors []string
+	seen := make(map[string]bool)
+	for _, item := range raw {
+		author, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'authors' must be an array of strings, got %T", item)
+		}
+		author = normalizeAuthor(author)
+		if author == "" {
+			return nil, fmt.Errorf("argument 'authors' must not contain empty names")
+		}
+		if !seen[author] {
+			seen[author] = true
+			authors = append(authors, author)
+		}
+	}
+	return authors, nil
+}
+
+// authors returns the name of every author with a set, as normalized when
+// it was stored.
+func (s *Service) authors(ctx context.Context) ([]string, error) {
+	var authors []string
+	var cursor uint64
+	for {
+		keys, next, err := s.store.Scan(ctx, cursor, authorKeyPrefix+"*", 0)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning authors: %v", err)
+		}
+		for _, key := range keys {
+			authors = append(authors, strings.TrimPrefix(key, authorKeyPrefix))
+		}
+
+		if next == 0 {
+			return authors, nil
+		}
+		cursor = next
+	}
+}
+
+func (s *Service) searchPapersByAuthor(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	author, ok := args["author"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'author' is missing or not a string")
+	}
+	author = normalizeAuthor(author)
+	if author == "" {
+		return nil, fmt.Errorf("argument 'author' must not be empty")
+	}
+
+	maxDistance, err := parseMaxDistance(args, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Author names are fuzzy-matched the way titles are, so a misspelt
+	// or differently spaced name still finds the papers.
+	names, err := s.authors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, name := range names {
+		if float64(levenshtein.ComputeDistance(author, name)) <= maxDistance {
+			matched = append(matched, name)
+		}
+	}
+	if len(matched) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No papers found by an author matching '%s'", author)), nil
+	}
+	sort.Strings(matched)
+
+	// A paper with several matching authors is listed once.
+	seen := make(map[string]bool)
+	var titles []string
+	for _, name := range matched {
+		members, err := s.store.Members(ctx, authorKeyPrefix+name)
+		if err != nil {
+			return nil, fmt.Errorf("error listing papers by '%s': %v", name, err)
+		}
+		for _, title := range members {
+			if !seen[title] {
+				seen[title] = true
+				titles = append(titles, title)
+			}
+		}
+	}
+
+	sort.Strings(titles)
+	result := fmt.Sprintf("Found %d papers by %s:\n", len(titles), strings.Join(quoted(matched), ", "))
+	for i, title := range titles {
+		result += fmt.Sprintf("%d. %s\n", i+1, title)
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// quoted wraps each of names in single quotes.
+func quoted(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = "'" + name + "'"
+	}
+	return out
+}
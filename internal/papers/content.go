@@ -0,0 +1,84 @@
+package papers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// contentMatch is one search-paper-content result.
+type contentMatch struct {
+	title string
+	count int
+}
+
+// searchPaperContent finds papers whose summaries contain the query.
+//
+// Redis cannot index the summaries, so every call scans all keys and reads
+// every summary: the cost is O(n) round trips in the number of stored
+// papers. limit only caps the size of the result.
+func (s *Service) searchPaperContent(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	query, ok := args["query"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'query' is missing or not a string")
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, fmt.Errorf("argument 'query' must not be empty")
+	}
+
+	limit := 10
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok || l < 1 || l != math.Trunc(l) {
+			return nil, fmt.Errorf("argument 'limit' must be a positive integer")
+		}
+		limit = int(l)
+	}
+
+	titles, err := s.titles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []contentMatch
+	for _, title := range titles {
+		summary, err := s.store.Get(ctx, title)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", title, err)
+		}
+		if count := strings.Count(strings.ToLower(summary), query); count > 0 {
+			matches = append(matches, contentMatch{title: title, count: count})
+		}
+	}
+
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No research paper content contains '%s'", query)), nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].count != matches[j].count {
+			return matches[i].count > matches[j].count
+		}
+		return matches[i].title < matches[j].title
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	result := fmt.Sprintf("Found %d papers containing '%s':\n", len(matches), query)
+	for i, m := range matches {
+		result += fmt.Sprintf("%d. %s (%d matches)\n", i+1, m.title, m.count)
+	}
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,72 @@
+package papers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MatchResult is the JSON output of get-research-paper. MatchType is
+// "exact", "fuzzy" or "none", and Distance is the edit distance of the
+// closest match: 0 for an exact match and null when nothing matched.
+type MatchResult struct {
+	Query     string       `json:"query"`
+	MatchType string       `json:"match_type"`
+	Distance  *int         `json:"distance"`
+	Matches   []PaperMatch `json:"matches"`
+}
+
+// PaperMatch is one paper in a MatchResult, closest first. Adjusted is only
+// set when a fuzzy bonus changed the distance.
+type PaperMatch struct {
+	Title         string   `json:"title"`
+	Summarization string   `json:"summarization"`
+	Distance      int      `json:"distance"`
+	Adjusted      *float64 `json:"adjusted,omitempty"`
+}
+
+// Match types reported in MatchResult.
+const (
+	matchExact = "exact"
+	matchFuzzy = "fuzzy"
+	matchNone  = "none"
+)
+
+// parseJSONFormat reads the optional format argument, reporting whether it
+// asks for JSON output.
+func parseJSONFormat(args map[string]any) (bool, error) {
+	raw, exists := args["format"]
+	if !exists {
+		return false, nil
+	}
+	format, ok := raw.(string)
+	if !ok {
+		return false, fmt.Errorf("argument 'format' must be a string")
+	}
+	switch format {
+	case "text":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("argument 'format' must be 'text' or 'json', got '%s'", format)
+	}
+}
+
+// matchResult renders the outcome of a get-research-paper lookup as a
+// MatchResult.
+func matchResult(query, matchType string, matches []PaperMatch) (*mcp.CallToolResult, error) {
+	result := MatchResult{Query: query, MatchType: matchType, Matches: matches}
+	if result.Matches == nil {
+		result.Matches = []PaperMatch{}
+	}
+	if len(matches) > 0 {
+		result.Distance = &matches[0].Distance
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding result: %v", err)
+	}
+	return mcp.NewToolResultText(string(out)), nil
+}
@@ -0,0 +1,136 @@
+package papers
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// scorer computes how far a stored title is from a query. Plain Levenshtein
+// distance penalizes length differences harshly, so the optional substring
+// and acronym components shrink the distance for abbreviations and partial
+// titles. With both weights at zero it is plain Levenshtein.
+type scorer struct {
+	substringWeight float64
+	acronymWeight   float64
+	// transpositions counts swapping two adjacent characters as one edit
+	// instead of two, as Damerau-Levenshtein distance does.
+	transpositions bool
+	// normalize compares titles by normalizeTitle, dropping stopwords too
+	// with dropStopwords, instead of by their lowercase form.
+	normalize     bool
+	dropStopwords bool
+}
+
+// form returns the text of s that distances are computed over.
+func (sc scorer) form(s string) string {
+	if sc.normalize {
+		return normalizeTitle(s, sc.dropStopwords)
+	}
+	return strings.ToLower(s)
+}
+
+// distance returns the raw edit distance between query and title and the
+// distance adjusted by the configured bonuses.
+func (sc scorer) distance(query, title string) (int, float64) {
+	q, t := sc.form(query), sc.form(title)
+	var raw int
+	if sc.transpositions {
+		raw = damerauLevenshtein(q, t)
+	} else {
+		raw = levenshtein.ComputeDistance(q, t)
+	}
+
+	bonus := 0.0
+	if sc.substringWeight > 0 && len(q) > 0 {
+		bonus += sc.substringWeight * float64(longestCommonSubstring(q, t)) / float64(len([]rune(q)))
+	}
+	if sc.acronymWeight > 0 && isAcronymOf(query, title) {
+		bonus += sc.acronymWeight
+	}
+	if bonus > 1 {
+		bonus = 1
+	}
+
+	return raw, float64(raw) * (1 - bonus)
+}
+
+// damerauLevenshtein returns the edit distance between a and b counting
+// insertions, deletions, substitutions and swaps of two adjacent runes as
+// one edit each. This is the optimal string alignment form, which edits no
+// substring more than once, so "teh" is one edit from "the".
+func damerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rows suffice: a transposition looks two rows back.
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// longestCommonSubstring returns the length in runes of the longest run of
+// characters shared by a and b.
+func longestCommonSubstring(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	best := 0
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			if ra[i-1] == rb[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > best {
+					best = curr[j]
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return best
+}
+
+// isAcronymOf reports whether query spells the initials of the words in
+// title, ignoring case and punctuation, e.g. "ML" or "m.l." for
+// "Machine Learning".
+func isAcronymOf(query, title string) bool {
+	var letters []rune
+	for _, r := range query {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters = append(letters, unicode.ToLower(r))
+		}
+	}
+
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(letters) < 2 || len(letters) != len(words) {
+		return false
+	}
+
+	for i, word := range words {
+		if unicode.ToLower([]rune(word)[0]) != letters[i] {
+			return false
+		}
+	}
+	return true
+}
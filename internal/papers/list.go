@@ -0,0 +1,83 @@
+package papers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultListCount is the SCAN count hint list-papers uses when none is
+// given, matching Redis's own default.
+const defaultListCount = 10
+
+func (s *Service) listPapers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	var cursor uint64
+	if cursorArg, exists := args["cursor"]; exists {
+		raw, ok := cursorArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'cursor' must be a string")
+		}
+		if raw != "" {
+			c, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("argument 'cursor' must be a cursor returned by list-papers, got '%s'", raw)
+			}
+			cursor = c
+		}
+	}
+	first := cursor == 0
+
+	count := int64(defaultListCount)
+	if countArg, exists := args["count"]; exists {
+		c, ok := countArg.(float64)
+		if !ok || c < 1 || c != math.Trunc(c) {
+			return nil, fmt.Errorf("argument 'count' must be a positive integer")
+		}
+		count = int64(c)
+	}
+
+	// Index keys share the keyspace with papers, so a page may hold none
+	// but them; scanning on until a title turns up avoids empty pages.
+	var titles []string
+	for {
+		keys, next, err := s.store.Scan(ctx, cursor, "*", count)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning keys: %v", err)
+		}
+		for _, key := range keys {
+			if !reservedKey(key) {
+				titles = append(titles, key)
+			}
+		}
+		cursor = next
+		if len(titles) > 0 || cursor == 0 {
+			break
+		}
+	}
+
+	if len(titles) == 0 {
+		if first {
+			return mcp.NewToolResultText("No research papers stored yet"), nil
+		}
+		return mcp.NewToolResultText("No more papers"), nil
+	}
+
+	sort.Strings(titles)
+	result := fmt.Sprintf("Listing %d papers:\n", len(titles))
+	for i, title := range titles {
+		result += fmt.Sprintf("%d. %s\n", i+1, title)
+	}
+	if cursor != 0 {
+		result += fmt.Sprintf("Next cursor: %d", cursor)
+	} else {
+		result += "No more papers"
+	}
+
+	return mcp.NewToolResultText(result), nil
+}
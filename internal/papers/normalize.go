@@ -0,0 +1,36 @@
+package papers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords are the short English words normalizeTitle can drop, which
+// titles often add or leave out without changing which paper they name.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// normalizeTitle reduces title to the form fuzzy matching compares when
+// normalization is on: lowercase words separated by single spaces, with
+// punctuation treated as a word break. With dropStopwords, stopwords are
+// removed too, unless the title consists of nothing else.
+func normalizeTitle(title string, dropStopwords bool) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if dropStopwords {
+		kept := make([]string, 0, len(words))
+		for _, word := range words {
+			if !stopwords[word] {
+				kept = append(kept, word)
+			}
+		}
+		if len(kept) > 0 {
+			words = kept
+		}
+	}
+	return strings.Join(words, " ")
+}
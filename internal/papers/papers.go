@@ -0,0 +1,587 @@
+package papers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/sanitize"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Config tunes the behavior of the research paper tools.
+type Config struct {
+	// SubstringWeight scales how much a long common substring between the
+	// query and a title reduces their fuzzy distance, from 0 to 1.
+	SubstringWeight float64
+	// AcronymWeight scales how much a query spelling a title's initials
+	// reduces their fuzzy distance, from 0 to 1.
+	AcronymWeight float64
+	// TitleWeight and SummaryWeight set how much title similarity and
+	// summary relevance each contribute to a search-papers score, from 0
+	// to 1.
+	TitleWeight   float64
+	SummaryWeight float64
+	// SanitizeInput strips control characters other than newlines and tabs
+	// from the summarization set-new-research-paper stores.
+	SanitizeInput bool
+}
+
+// DefaultConfig returns the configuration used when nothing is overridden.
+// Both fuzzy weights are zero, so matching is plain Levenshtein distance,
+// and search-papers weighs titles and summaries equally. Input is
+// sanitized.
+func DefaultConfig() Config {
+	return Config{
+		TitleWeight:   0.5,
+		SummaryWeight: 0.5,
+		SanitizeInput: true,
+	}
+}
+
+// Validate reports configuration values outside their allowed range.
+func (c Config) Validate() error {
+	if c.SubstringWeight < 0 || c.SubstringWeight > 1 {
+		return fmt.Errorf("substring weight must be between 0 and 1, got %g", c.SubstringWeight)
+	}
+	if c.AcronymWeight < 0 || c.AcronymWeight > 1 {
+		return fmt.Errorf("acronym weight must be between 0 and 1, got %g", c.AcronymWeight)
+	}
+	if c.TitleWeight < 0 || c.TitleWeight > 1 {
+		return fmt.Errorf("title weight must be between 0 and 1, got %g", c.TitleWeight)
+	}
+	if c.SummaryWeight < 0 || c.SummaryWeight > 1 {
+		return fmt.Errorf("summary weight must be between 0 and 1, got %g", c.SummaryWeight)
+	}
+	if c.TitleWeight == 0 && c.SummaryWeight == 0 {
+		return fmt.Errorf("title and summary weights cannot both be zero")
+	}
+	return nil
+}
+
+// ConfigFromEnv reads FUZZY_SUBSTRING_WEIGHT, FUZZY_ACRONYM_WEIGHT,
+// SEARCH_TITLE_WEIGHT, SEARCH_SUMMARY_WEIGHT and SANITIZE_INPUT over
+// DefaultConfig and validates the result. Every value that does not parse
+// is reported, not just the first.
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+	var errs []error
+	var err error
+	cfg.SubstringWeight, err = env.Float("FUZZY_SUBSTRING_WEIGHT", cfg.SubstringWeight)
+	errs = append(errs, err)
+	cfg.AcronymWeight, err = env.Float("FUZZY_ACRONYM_WEIGHT", cfg.AcronymWeight)
+	errs = append(errs, err)
+	cfg.TitleWeight, err = env.Float("SEARCH_TITLE_WEIGHT", cfg.TitleWeight)
+	errs = append(errs, err)
+	cfg.SummaryWeight, err = env.Float("SEARCH_SUMMARY_WEIGHT", cfg.SummaryWeight)
+	errs = append(errs, err)
+	cfg.SanitizeInput, err = env.Bool("SANITIZE_INPUT", cfg.SanitizeInput)
+	errs = append(errs, err)
+	// Validation is left until every value parsed, since a value that did
+	// not would be reported twice.
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Service implements the research paper tools on top of a key-value store.
+type Service struct {
+	store  Store
+	cfg    Config
+	scorer scorer
+}
+
+// NewService returns a Service storing papers in store.
+func NewService(store Store, cfg Config) *Service {
+	return &Service{
+		store: store,
+		cfg:   cfg,
+		scorer: scorer{
+			substringWeight: cfg.SubstringWeight,
+			acronymWeight:   cfg.AcronymWeight,
+		},
+	}
+}
+
+// Tools returns the research paper tools ready to be registered on an MCP server.
+func (s *Service) Tools() []server.ServerTool {
+	setNewResearchPaper := mcp.NewTool("set-new-research-paper",
+		mcp.WithDescription("Add a new research paper"),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("The name of the paper"),
+		),
+		mcp.WithString("summarization",
+			mcp.Description("The main content of the paper"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Topics to file the paper under, e.g. [\"ml\", \"nlp\"]. Tags are case-insensitive and are added to any the paper already has."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("authors",
+			mcp.Description("Names of the paper's authors, e.g. [\"Ashish Vaswani\", \"Noam Shazeer\"]. Names are case-insensitive and are added to any the paper already has."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithBoolean("append",
+			mcp.Description("Append the summarization to the stored one instead of replacing it, creating the paper when it does not exist yet (default: false)"),
+		),
+		mcp.WithString("separator",
+			mcp.Description("Text placed between the stored summarization and the appended one (default: a blank line)"),
+		),
+	)
+
+	getResearchPaper := mcp.NewTool("get-research-paper",
+		mcp.WithDescription("Get the content of a research paper based on its name"),
+		mcp.WithString("title",
+			mcp.Required(),
+			mcp.Description("The name of the paper"),
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description("Largest edit distance accepted for a fuzzy match (default: 3). In relative mode, a fraction of the title length between 0 and 1 (default: 0.25)."),
+		),
+		mcp.WithBoolean("relative",
+			mcp.Description("Interpret max_distance relative to the length of each title, so long titles tolerate more typos than short ones (default: false)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Number of close matches to return, closest first with ties ordered alphabetically (default: 1). An exact title match is always returned alone."),
+		),
+		mcp.WithString("match_mode",
+			mcp.Description("How to match a misspelled title: 'edit' (default) by edit distance, or 'phonetic' by titles that sound alike (equal Metaphone codes), falling back to edit distance when none does"),
+			mcp.Enum("edit", "phonetic"),
+		),
+		mcp.WithString("distance_algo",
+			mcp.Description("Edit distance used for fuzzy matching: 'levenshtein' (default), or 'damerau-levenshtein', which counts swapping two adjacent characters, as in 'teh', as one edit instead of two"),
+			mcp.Enum("levenshtein", "damerau-levenshtein"),
+		),
+		mcp.WithBoolean("normalize",
+			mcp.Description("Compare titles ignoring punctuation and extra whitespace, so 'Attention: Is All You Need!' matches 'attention is all you need' exactly (default: false). Matches are still shown by their stored titles."),
+		),
+		mcp.WithBoolean("remove_stopwords",
+			mcp.Description("With normalize, also ignore short words such as 'a', 'the' and 'of', so 'A Study of Graphs' matches 'Study Graphs' (default: false)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'text' (default) or 'json', an object with the query, a match_type of 'exact', 'fuzzy' or 'none', the distance of the closest match (null for none) and the matches with their title, summarization and distance"),
+			mcp.Enum("text", "json"),
+		),
+	)
+
+	searchPapers := mcp.NewTool("search-papers",
+		mcp.WithDescription("Rank research papers by a weighted combination of fuzzy title similarity and keyword relevance of their summaries to the query"),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Search query text"),
+		),
+		mcp.WithNumber("top_k",
+			mcp.Description("Number of results to return (default: 5)"),
+		),
+	)
+
+	searchPaperContent := mcp.NewTool("search-paper-content",
+		mcp.WithDescription("Find research papers whose summarization contains a phrase, case-insensitive, ranked by how often it occurs. Scans every stored paper, so the cost grows linearly with the collection."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Phrase to look for in paper summaries"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of titles to return (default: 10)"),
+		),
+	)
+
+	listPapersByTag := mcp.NewTool("list-papers-by-tag",
+		mcp.WithDescription("List the titles of all research papers filed under a tag"),
+		mcp.WithString("tag",
+			mcp.Required(),
+			mcp.Description("The tag to list, case-insensitive"),
+		),
+	)
+
+	searchPapersByAuthor := mcp.NewTool("search-papers-by-author",
+		mcp.WithDescription("List the titles of all research papers by an author, matching the author's name fuzzily so a misspelling still finds them"),
+		mcp.WithString("author",
+			mcp.Required(),
+			mcp.Description("The author's name, case-insensitive"),
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description(fmt.Sprintf("Largest edit distance between the given name and a stored author's name (default: %d)", defaultMaxDistance)),
+		),
+	)
+
+	checkPaperAliases := mcp.NewTool("check-paper-aliases",
+		mcp.WithDescription("Find case-insensitive title aliases whose paper no longer exists, such as after a paper was deleted without cleaning up its alias, and optionally remove them"),
+		mcp.WithBoolean("remove",
+			mcp.Description("Delete the orphaned aliases found instead of only reporting them (default: false)"),
+		),
+	)
+
+	listPapers := mcp.NewTool("list-papers",
+		mcp.WithDescription("List the titles of stored research papers one page at a time, following Redis SCAN's cursor so large collections are never read in one go"),
+		mcp.WithString("cursor",
+			mcp.Description("Cursor returned by the previous call to continue from (default: start from the beginning)"),
+		),
+		mcp.WithNumber("count",
+			mcp.Description(fmt.Sprintf("How many keys to scan per page; a hint, so a page may hold somewhat more or fewer titles (default: %d)", defaultListCount)),
+		),
+	)
+
+	return []server.ServerTool{
+		{Tool: setNewResearchPaper, Handler: s.setNewResearchPaper},
+		{Tool: getResearchPaper, Handler: s.getResearchPaper},
+		{Tool: searchPapers, Handler: s.searchPapers},
+		{Tool: searchPaperContent, Handler: s.searchPaperContent},
+		{Tool: listPapersByTag, Handler: s.listPapersByTag},
+		{Tool: searchPapersByAuthor, Handler: s.searchPapersByAuthor},
+		{Tool: listPapers, Handler: s.listPapers},
+		{Tool: checkPaperAliases, Handler: s.checkPaperAliases},
+	}
+}
+
+func (s *Service) setNewResearchPaper(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	title, ok := args["title"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'title' is missing or not a string")
+	}
+
+	if strings.HasPrefix(title, tagKeyPrefix) {
+		return nil, fmt.Errorf("titles may not start with '%s', which is reserved for tags", tagKeyPrefix)
+	}
+	if strings.HasPrefix(title, authorKeyPrefix) {
+		return nil, fmt.Errorf("titles may not start with '%s', which is reserved for authors", authorKeyPrefix)
+	}
+	if strings.HasPrefix(title, titleKeyPrefix) {
+		return nil, fmt.Errorf("titles may not start with '%s', which is reserved for the title index", titleKeyPrefix)
+	}
+
+	summarization, _ := args["summarization"].(string)
+	if s.cfg.SanitizeInput {
+		summarization = sanitize.Text(summarization)
+	}
+
+	tags, err := parseTags(args["tags"])
+	if err != nil {
+		return nil, err
+	}
+	authors, err := parseAuthors(args["authors"])
+	if err != nil {
+		return nil, err
+	}
+
+	appendMode, _ := args["append"].(bool)
+	created := false
+	if appendMode {
+		separator := defaultAppendSeparator
+		if sepArg, exists := args["separator"]; exists {
+			if separator, ok = sepArg.(string); !ok {
+				return nil, fmt.Errorf("argument 'separator' must be a string")
+			}
+		}
+
+		// The read and the write are separate calls, so two appends to the
+		// same paper at once may lose one of them.
+		existing, err := s.store.Get(ctx, title)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			created = true
+		case err != nil:
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", title, err)
+		case existing != "" && summarization != "":
+			summarization = existing + separator + summarization
+		default:
+			summarization = existing + summarization
+		}
+	}
+
+	setErr := s.store.Set(ctx, title, summarization)
+	if setErr != nil {
+		fmt.Println(setErr)
+		return nil, setErr
+	}
+	if err := s.store.Set(ctx, titleKey(title), title); err != nil {
+		return nil, fmt.Errorf("error indexing title '%s': %v", title, err)
+	}
+
+	for _, tag := range tags {
+		if err := s.store.AddToSet(ctx, tagKey(tag), title); err != nil {
+			return nil, fmt.Errorf("error tagging paper '%s' with '%s': %v", title, tag, err)
+		}
+	}
+	for _, author := range authors {
+		if err := s.store.AddToSet(ctx, authorKey(author), title); err != nil {
+			return nil, fmt.Errorf("error recording '%s' as an author of '%s': %v", author, title, err)
+		}
+	}
+
+	switch {
+	case appendMode && created:
+		return mcp.NewToolResultText(fmt.Sprintf("Created research paper '%s'", title)), nil
+	case appendMode:
+		return mcp.NewToolResultText(fmt.Sprintf("Appended to research paper '%s'", title)), nil
+	}
+	return mcp.NewToolResultText("Successful update of the knowledge base"), nil
+}
+
+// defaultAppendSeparator goes between the stored summarization and the
+// appended text.
+const defaultAppendSeparator = "\n\n"
+
+func (s *Service) getResearchPaper(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	title, ok := args["title"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'title' is missing or not a string")
+	}
+
+	jsonOut, err := parseJSONFormat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// First try exact match, where a title differing only in case still
+	// counts. Tag and author sets, the title index and records are not
+	// papers.
+	if !reservedKey(title) {
+		val, err := s.store.Get(ctx, title)
+		if err == nil {
+			if jsonOut {
+				return matchResult(title, matchExact, []PaperMatch{{Title: title, Summarization: val}})
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Found exact match for '%s': %s", title, val)), nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", title, err)
+		}
+
+		canonical, err := s.canonicalTitle(ctx, title)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up title '%s': %v", title, err)
+		}
+		if canonical != "" {
+			val, err := s.store.Get(ctx, canonical)
+			if err == nil {
+				if jsonOut {
+					return matchResult(title, matchExact, []PaperMatch{{Title: canonical, Summarization: val}})
+				}
+				return mcp.NewToolResultText(fmt.Sprintf("Found exact match for '%s' (ignoring case): %s", canonical, val)), nil
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return nil, fmt.Errorf("error retrieving content for key '%s': %v", canonical, err)
+			}
+		}
+	}
+
+	relative, _ := args["relative"].(bool)
+	maxDistance, err := parseMaxDistance(args, relative)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := 1
+	if limitArg, exists := args["limit"]; exists {
+		l, ok := limitArg.(float64)
+		if !ok || l < 1 || l != math.Trunc(l) {
+			return nil, fmt.Errorf("argument 'limit' must be a positive integer")
+		}
+		limit = int(l)
+	}
+
+	phonetic := false
+	if modeArg, exists := args["match_mode"]; exists {
+		mode, ok := modeArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'match_mode' must be a string")
+		}
+		switch mode {
+		case "edit":
+		case "phonetic":
+			phonetic = true
+		default:
+			return nil, fmt.Errorf("argument 'match_mode' must be 'edit' or 'phonetic', got '%s'", mode)
+		}
+	}
+
+	sc := s.scorer
+	if algoArg, exists := args["distance_algo"]; exists {
+		algo, ok := algoArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'distance_algo' must be a string")
+		}
+		switch algo {
+		case "levenshtein":
+		case "damerau-levenshtein":
+			sc.transpositions = true
+		default:
+			return nil, fmt.Errorf("argument 'distance_algo' must be 'levenshtein' or 'damerau-levenshtein', got '%s'", algo)
+		}
+	}
+	sc.normalize, _ = args["normalize"].(bool)
+	sc.dropStopwords, _ = args["remove_stopwords"].(bool)
+	if sc.dropStopwords && !sc.normalize {
+		return nil, fmt.Errorf("argument 'remove_stopwords' requires 'normalize'")
+	}
+
+	// If exact match fails, try fuzzy matching
+	titles, err := s.titles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Papers stored before the title index existed are only found by case
+	// here.
+	for _, key := range titles {
+		if strings.EqualFold(key, title) {
+			val, err := s.store.Get(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("error retrieving content for key '%s': %v", key, err)
+			}
+			if jsonOut {
+				return matchResult(title, matchExact, []PaperMatch{{Title: key, Summarization: val}})
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Found exact match for '%s' (ignoring case): %s", key, val)), nil
+		}
+	}
+
+	if phonetic {
+		result, err := s.phoneticMatches(ctx, title, titles, limit, sc, jsonOut)
+		if err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	var matches []fuzzyMatch
+	for _, key := range titles {
+		distance, adjusted := sc.distance(title, key)
+
+		threshold := maxDistance
+		if relative {
+			threshold *= float64(max(utf8.RuneCountInString(sc.form(title)), utf8.RuneCountInString(sc.form(key))))
+		}
+
+		if adjusted <= threshold {
+			matches = append(matches, fuzzyMatch{title: key, distance: distance, adjusted: adjusted})
+		}
+	}
+
+	if len(matches) == 0 {
+		if jsonOut {
+			return matchResult(title, matchNone, nil)
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("No research paper found matching '%s'", title)), nil
+	}
+
+	// Closest first, ties alphabetically so the output is deterministic.
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].adjusted != matches[j].adjusted {
+			return matches[i].adjusted < matches[j].adjusted
+		}
+		return matches[i].title < matches[j].title
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	for i := range matches {
+		// Get the content of each match
+		matches[i].value, err = s.store.Get(ctx, matches[i].title)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", matches[i].title, err)
+		}
+	}
+
+	if jsonOut {
+		found := make([]PaperMatch, len(matches))
+		for i, m := range matches {
+			found[i] = PaperMatch{Title: m.title, Summarization: m.value, Distance: m.distance}
+			if m.adjusted != float64(m.distance) {
+				found[i].Adjusted = &m.adjusted
+			}
+		}
+		return matchResult(title, matchFuzzy, found)
+	}
+
+	if limit == 1 {
+		best := matches[0]
+		return mcp.NewToolResultText(fmt.Sprintf("Found closest match %s: %s", best.describe(), best.value)), nil
+	}
+
+	result := fmt.Sprintf("Found %d close matches for '%s':\n", len(matches), title)
+	for i, m := range matches {
+		result += fmt.Sprintf("%d. %s: %s\n", i+1, m.describe(), preview(m.value, previewRunes))
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// fuzzyMatch is a stored title within the edit distance threshold.
+type fuzzyMatch struct {
+	title    string
+	value    string
+	distance int
+	adjusted float64
+}
+
+// describe names the match with its distance, and its adjusted distance
+// when a fuzzy bonus changed it.
+func (m fuzzyMatch) describe() string {
+	if m.adjusted != float64(m.distance) {
+		return fmt.Sprintf("'%s' (distance: %d, adjusted: %.2f)", m.title, m.distance, m.adjusted)
+	}
+	return fmt.Sprintf("'%s' (distance: %d)", m.title, m.distance)
+}
+
+// previewRunes is how much of each summary a multi-match answer shows.
+const previewRunes = 80
+
+// preview shortens content to at most limit runes, marking the cut with
+// an ellipsis.
+func preview(content string, limit int) string {
+	runes := []rune(content)
+	if len(runes) <= limit {
+		return content
+	}
+	return string(runes[:limit]) + "..."
+}
+
+const (
+	// defaultMaxDistance is the largest edit distance get-research-paper
+	// accepts when max_distance is not given.
+	defaultMaxDistance = 3
+	// defaultRelativeDistance is the default max_distance in relative mode,
+	// as a fraction of the longer of the query and the title.
+	defaultRelativeDistance = 0.25
+)
+
+// parseMaxDistance reads the optional max_distance argument: a
+// non-negative whole number of edits, or in relative mode a fraction of the
+// title length between 0 and 1.
+func parseMaxDistance(args map[string]any, relative bool) (float64, error) {
+	arg, exists := args["max_distance"]
+	if !exists {
+		if relative {
+			return defaultRelativeDistance, nil
+		}
+		return defaultMaxDistance, nil
+	}
+
+	maxDistance, ok := arg.(float64)
+	if !ok {
+		return 0, fmt.Errorf("argument 'max_distance' must be a number")
+	}
+	if relative {
+		if maxDistance < 0 || maxDistance > 1 {
+			return 0, fmt.Errorf("argument 'max_distance' must be between 0 and 1 in relative mode, got %g", maxDistance)
+		}
+		return maxDistance, nil
+	}
+	if maxDistance < 0 || maxDistance != math.Trunc(maxDistance) {
+		return 0, fmt.Errorf("argument 'max_distance' must be a non-negative integer, got %g", maxDistance)
+	}
+	return maxDistance, nil
+}
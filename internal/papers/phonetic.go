@@ -0,0 +1,233 @@
+package papers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// metaphone returns the Metaphone code of text, one code per word joined by
+// spaces, so titles that sound alike share a code even when their spelling
+// is far apart, e.g. "Fonetic" and "Phonetic". Characters outside A-Z are
+// ignored.
+func metaphone(text string) string {
+	words := strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var codes []string
+	for _, word := range words {
+		if code := metaphoneWord(word); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, " ")
+}
+
+// metaphoneWord encodes one upper case word following Lawrence Philips'
+// original Metaphone rules. '0' stands for the "th" sound.
+func metaphoneWord(word string) string {
+	// Keep only A-Z and collapse doubled letters other than C.
+	var w []byte
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if c < 'A' || c > 'Z' {
+			continue
+		}
+		if len(w) > 0 && w[len(w)-1] == c && c != 'C' {
+			continue
+		}
+		w = append(w, c)
+	}
+	if len(w) == 0 {
+		return ""
+	}
+
+	// Silent or changed initial letters.
+	switch {
+	case len(w) > 1 && (string(w[:2]) == "AE" || string(w[:2]) == "GN" || string(w[:2]) == "KN" || string(w[:2]) == "PN" || string(w[:2]) == "WR"):
+		w = w[1:]
+	case w[0] == 'X':
+		w[0] = 'S'
+	case len(w) > 1 && string(w[:2]) == "WH":
+		w = append([]byte{'W'}, w[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	isVowel := func(c byte) bool {
+		return c == 'A' || c == 'E' || c == 'I' || c == 'O' || c == 'U'
+	}
+	frontVowel := func(c byte) bool {
+		return c == 'E' || c == 'I' || c == 'Y'
+	}
+
+	var code strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				code.WriteByte(c)
+			}
+		case 'B':
+			// Silent in a final "MB", as in "dumb".
+			if !(i == len(w)-1 && at(i-1) == 'M') {
+				code.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case at(i+1) == 'I' && at(i+2) == 'A':
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				if at(i-1) == 'S' {
+					code.WriteByte('K')
+				} else {
+					code.WriteByte('X')
+				}
+				i++
+			case frontVowel(at(i + 1)):
+				if at(i-1) != 'S' {
+					code.WriteByte('S')
+				}
+			default:
+				code.WriteByte('K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && frontVowel(at(i+2)) {
+				code.WriteByte('J')
+				i++
+			} else {
+				code.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case at(i+1) == 'H' && i+2 < len(w) && !isVowel(at(i+2)):
+				// Silent, as in "night".
+			case at(i+1) == 'N' && (i+2 == len(w) || string(w[i+1:]) == "NED"):
+				// Silent, as in "sign" and "signed".
+			case frontVowel(at(i+1)) && at(i-1) != 'G':
+				code.WriteByte('J')
+			default:
+				code.WriteByte('K')
+			}
+		case 'H':
+			// Silent after a vowel with no vowel following, and after
+			// letters it merely modifies.
+			if isVowel(at(i+1)) && !strings.ContainsRune("CSPTG", rune(at(i-1))) {
+				code.WriteByte('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				code.WriteByte('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				code.WriteByte('F')
+				i++
+			} else {
+				code.WriteByte('P')
+			}
+		case 'Q':
+			code.WriteByte('K')
+		case 'S':
+			switch {
+			case at(i+1) == 'H':
+				code.WriteByte('X')
+				i++
+			case at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				code.WriteByte('X')
+			default:
+				code.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				code.WriteByte('0')
+				i++
+			case at(i+1) == 'C' && at(i+2) == 'H':
+				// Silent, as in "match".
+			default:
+				code.WriteByte('T')
+			}
+		case 'V':
+			code.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(at(i + 1)) {
+				code.WriteByte(c)
+			}
+		case 'X':
+			code.WriteString("KS")
+		case 'Z':
+			code.WriteByte('S')
+		default:
+			// F, J, L, M, N and R sound as written.
+			code.WriteByte(c)
+		}
+	}
+	return code.String()
+}
+
+// phoneticMatches answers get-research-paper with the titles among titles
+// whose Metaphone code equals that of query, alphabetically and at most
+// limit of them. It returns nil when none sounds alike. With jsonOut it
+// reports each match's edit distance as measured by sc.
+func (s *Service) phoneticMatches(ctx context.Context, query string, titles []string, limit int, sc scorer, jsonOut bool) (*mcp.CallToolResult, error) {
+	code := metaphone(query)
+	if code == "" {
+		return nil, nil
+	}
+
+	var matches []string
+	for _, title := range titles {
+		if metaphone(title) == code {
+			matches = append(matches, title)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	values := make([]string, len(matches))
+	for i, title := range matches {
+		value, err := s.store.Get(ctx, title)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", title, err)
+		}
+		values[i] = value
+	}
+
+	if jsonOut {
+		found := make([]PaperMatch, len(matches))
+		for i, title := range matches {
+			distance, _ := sc.distance(query, title)
+			found[i] = PaperMatch{Title: title, Summarization: values[i], Distance: distance}
+		}
+		return matchResult(query, matchFuzzy, found)
+	}
+
+	if limit == 1 {
+		return mcp.NewToolResultText(fmt.Sprintf("Found phonetic match '%s' (metaphone: %s): %s", matches[0], code, values[0])), nil
+	}
+
+	result := fmt.Sprintf("Found %d phonetic matches for '%s' (metaphone: %s):\n", len(matches), query, code)
+	for i, title := range matches {
+		result += fmt.Sprintf("%d. '%s': %s\n", i+1, title, preview(values[i], previewRunes))
+	}
+	return mcp.NewToolResultText(result), nil
+}
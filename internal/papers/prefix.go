@@ -0,0 +1,72 @@
+package papers
+
+import (
+	"context"
+	"strings"
+)
+
+// prefixStore is a Store keeping every key under a fixed prefix, so several
+// services can share one Redis database without their keys colliding.
+type prefixStore struct {
+	store  Store
+	prefix string
+}
+
+// WithKeyPrefix returns store with prefix prepended to every key it reads or
+// writes. Scans only see keys under the prefix and return them without it,
+// so the tools work with bare titles either way. An empty prefix returns
+// store unchanged.
+func WithKeyPrefix(store Store, prefix string) Store {
+	if prefix == "" {
+		return store
+	}
+	return prefixStore{store: store, prefix: prefix}
+}
+
+func (p prefixStore) Get(ctx context.Context, key string) (string, error) {
+	return p.store.Get(ctx, p.prefix+key)
+}
+
+func (p prefixStore) Set(ctx context.Context, key, value string) error {
+	return p.store.Set(ctx, p.prefix+key, value)
+}
+
+func (p prefixStore) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	keys, next, err := p.store.Scan(ctx, cursor, globEscape(p.prefix)+match, count)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := keys[:0]
+	for _, key := range keys {
+		if bare, ok := strings.CutPrefix(key, p.prefix); ok {
+			out = append(out, bare)
+		}
+	}
+	return out, next, nil
+}
+
+func (p prefixStore) Delete(ctx context.Context, key string) error {
+	return p.store.Delete(ctx, p.prefix+key)
+}
+
+func (p prefixStore) AddToSet(ctx context.Context, key, member string) error {
+	return p.store.AddToSet(ctx, p.prefix+key, member)
+}
+
+func (p prefixStore) Members(ctx context.Context, key string) ([]string, error) {
+	return p.store.Members(ctx, p.prefix+key)
+}
+
+// globEscape quotes the characters SCAN's MATCH pattern treats specially,
+// so s matches only itself.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package papers
+
+import (
+	"context"
+
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+)
+
+// retryStore is a Store that retries every call failing with a transient
+// error according to policy.
+type retryStore struct {
+	store  Store
+	policy retry.Policy
+}
+
+// WithRetry returns store with every call retried on transient errors
+// according to policy.
+func WithRetry(store Store, policy retry.Policy) Store {
+	return retryStore{store: store, policy: policy}
+}
+
+func (r retryStore) Get(ctx context.Context, key string) (string, error) {
+	return retry.Value(ctx, r.policy, func() (string, error) { return r.store.Get(ctx, key) })
+}
+
+func (r retryStore) Set(ctx context.Context, key, value string) error {
+	return r.policy.Do(ctx, func() error { return r.store.Set(ctx, key, value) })
+}
+
+func (r retryStore) Delete(ctx context.Context, key string) error {
+	return r.policy.Do(ctx, func() error { return r.store.Delete(ctx, key) })
+}
+
+func (r retryStore) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	var next uint64
+	keys, err := retry.Value(ctx, r.policy, func() ([]string, error) {
+		var (
+			keys []string
+			err  error
+		)
+		keys, next, err = r.store.Scan(ctx, cursor, match, count)
+		return keys, err
+	})
+	return keys, next, err
+}
+
+func (r retryStore) AddToSet(ctx context.Context, key, member string) error {
+	return r.policy.Do(ctx, func() error { return r.store.AddToSet(ctx, key, member) })
+}
+
+func (r retryStore) Members(ctx context.Context, key string) ([]string, error) {
+	return retry.Value(ctx, r.policy, func() ([]string, error) { return r.store.Members(ctx, key) })
+}
@@ -0,0 +1,148 @@
+package papers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// paperMatch is one ranked search-papers result.
+type paperMatch struct {
+	title   string
+	summary string
+	// titleScore and summaryScore are the components, each from 0 to 1.
+	titleScore   float64
+	summaryScore float64
+	// score is the weighted average of the components.
+	score float64
+}
+
+func (s *Service) searchPapers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("argument 'query' is missing or empty")
+	}
+
+	topK := 5
+	if topKArg, exists := args["top_k"]; exists {
+		if topKFloat, ok := topKArg.(float64); ok {
+			topK = int(topKFloat)
+		} else if topKStr, ok := topKArg.(string); ok {
+			if parsed, err := strconv.Atoi(topKStr); err == nil {
+				topK = parsed
+			}
+		}
+		if topK < 1 {
+			return nil, fmt.Errorf("argument 'top_k' must be at least 1, got %d", topK)
+		}
+	}
+
+	titles, err := s.titles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []paperMatch
+	for _, title := range titles {
+		summary, err := s.store.Get(ctx, title)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", title, err)
+		}
+
+		m := paperMatch{
+			title:        title,
+			summary:      summary,
+			titleScore:   s.titleSimilarity(query, title),
+			summaryScore: summaryRelevance(query, summary),
+		}
+		if total := s.cfg.TitleWeight + s.cfg.SummaryWeight; total > 0 {
+			m.score = (s.cfg.TitleWeight*m.titleScore + s.cfg.SummaryWeight*m.summaryScore) / total
+		}
+		if m.score > 0 {
+			matches = append(matches, m)
+		}
+	}
+
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No research paper found matching '%s'", query)), nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].title < matches[j].title
+	})
+	if len(matches) > topK {
+		matches = matches[:topK]
+	}
+
+	result := fmt.Sprintf("Found %d papers:\n", len(matches))
+	for i, m := range matches {
+		result += fmt.Sprintf("%d. %s (score: %.4f, title: %.4f, summary: %.4f): %s\n", i+1, m.title, m.score, m.titleScore, m.summaryScore, m.summary)
+	}
+	return mcp.NewToolResultText(result), nil
+}
+
+// titles returns every stored paper title, skipping the tag and author
+// sets and the title index.
+func (s *Service) titles(ctx context.Context) ([]string, error) {
+	var titles []string
+	var cursor uint64
+	for {
+		keys, next, err := s.store.Scan(ctx, cursor, "*", 0)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning keys: %v", err)
+		}
+		for _, key := range keys {
+			if !reservedKey(key) {
+				titles = append(titles, key)
+			}
+		}
+
+		if next == 0 {
+			return titles, nil
+		}
+		cursor = next
+	}
+}
+
+// titleSimilarity turns the fuzzy distance between query and title into a
+// similarity from 0 (nothing alike) to 1 (identical).
+func (s *Service) titleSimilarity(query, title string) float64 {
+	longest := max(utf8.RuneCountInString(query), utf8.RuneCountInString(title))
+	if longest == 0 {
+		return 0
+	}
+	_, adjusted := s.scorer.distance(query, title)
+	return max(0, 1-adjusted/float64(longest))
+}
+
+// summaryRelevance scores summary against query from 0 to 1: 1 when the
+// whole query appears in it, otherwise the fraction of query words it
+// contains.
+func summaryRelevance(query, summary string) float64 {
+	q, sum := strings.ToLower(strings.TrimSpace(query)), strings.ToLower(summary)
+	if q == "" || sum == "" {
+		return 0
+	}
+	if strings.Contains(sum, q) {
+		return 1
+	}
+
+	words := strings.Fields(q)
+	found := 0
+	for _, word := range words {
+		if strings.Contains(sum, strings.Trim(word, ".,;:!?\"'()")) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words))
+}
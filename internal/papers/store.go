@@ -0,0 +1,80 @@
+package papers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned by a Store when a key does not exist.
+var ErrNotFound = errors.New("key not found")
+
+// Store is the key-value API used by the research paper tools.
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+	// Delete removes key, doing nothing when it does not exist.
+	Delete(ctx context.Context, key string) error
+	// Scan returns one page of keys matching the pattern together with the
+	// cursor for the next page. A returned cursor of 0 ends the iteration.
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	// AddToSet adds member to the set stored at key, creating it if needed.
+	AddToSet(ctx context.Context, key, member string) error
+	// Members returns the members of the set stored at key, or none when
+	// it does not exist.
+	Members(ctx context.Context, key string) ([]string, error)
+}
+
+// RedisStore is a Store backed by a Redis client.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// ParseRedisURL parses the REDIS_URL setting into client options,
+// rejecting an empty or malformed URL so the server fails at startup
+// rather than on its first tool call.
+func ParseRedisURL(url string) (*redis.Options, error) {
+	if url == "" {
+		return nil, errors.New("REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL is invalid: %v", err)
+	}
+	return opt, nil
+}
+
+// NewRedisStore returns a Store using client.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) (string, error) {
+	val, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+func (r *RedisStore) Set(ctx context.Context, key, value string) error {
+	return r.client.Set(ctx, key, value, 0).Err()
+}
+
+func (r *RedisStore) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+func (r *RedisStore) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return r.client.Scan(ctx, cursor, match, count).Result()
+}
+
+func (r *RedisStore) AddToSet(ctx context.Context, key, member string) error {
+	return r.client.SAdd(ctx, key, member).Err()
+}
+
+func (r *RedisStore) Members(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
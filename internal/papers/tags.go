@@ -0,0 +1,83 @@
+package papers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// tagKeyPrefix starts the key of every tag set, which holds the titles of
+// the papers carrying the tag. Titles may not start with it.
+const tagKeyPrefix = "tag:"
+
+// tagKey returns the key of the set for tag.
+func tagKey(tag string) string {
+	return tagKeyPrefix + tag
+}
+
+// normalizeTag trims and lowercases tag, so "ML" and "ml " name the same
+// tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// parseTags reads the optional tags argument, an array of strings.
+func parseTags(arg any) ([]string, error) {
+	if arg == nil {
+		return nil, nil
+	}
+	raw, ok := arg.([]any)
+	if !ok {
+		return nil, fmt.Errorf("argument 'tags' must be an array of strings")
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, item := range raw {
+		tag, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument 'tags' must be an array of strings, got %T", item)
+		}
+		tag = normalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("argument 'tags' must not contain empty tags")
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *Service) listPapersByTag(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	tag, ok := args["tag"].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument 'tag' is missing or not a string")
+	}
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return nil, fmt.Errorf("argument 'tag' must not be empty")
+	}
+
+	titles, err := s.store.Members(ctx, tagKey(tag))
+	if err != nil {
+		return nil, fmt.Errorf("error listing papers tagged '%s': %v", tag, err)
+	}
+
+	if len(titles) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No papers are tagged '%s' yet", tag)), nil
+	}
+
+	sort.Strings(titles)
+	result := fmt.Sprintf("Found %d papers tagged '%s':\n", len(titles), tag)
+	for i, title := range titles {
+		result += fmt.Sprintf("%d. %s\n", i+1, title)
+	}
+	return mcp.NewToolResultText(result), nil
+}
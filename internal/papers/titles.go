@@ -0,0 +1,104 @@
+package papers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// titleKeyPrefix starts the key mapping the lowercase form of each title to
+// the title as stored, so a lookup differing only in case is still exact.
+// Titles may not start with it.
+const titleKeyPrefix = "title:"
+
+// reservedKey reports whether key holds a tag set, an author set or a
+// title index entry rather than a paper.
+func reservedKey(key string) bool {
+	return strings.HasPrefix(key, tagKeyPrefix) || strings.HasPrefix(key, authorKeyPrefix) || strings.HasPrefix(key, titleKeyPrefix)
+}
+
+// titleKey returns the key holding the stored title for title, whatever
+// its case.
+func titleKey(title string) string {
+	return titleKeyPrefix + strings.ToLower(title)
+}
+
+// canonicalTitle returns the stored title that equals title ignoring case,
+// or "" when the index has none.
+func (s *Service) canonicalTitle(ctx context.Context, title string) (string, error) {
+	canonical, err := s.store.Get(ctx, titleKey(title))
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	return canonical, err
+}
+
+// orphanedAlias is a title index entry whose paper no longer exists.
+type orphanedAlias struct {
+	key       string
+	canonical string
+}
+
+func (s *Service) checkPaperAliases(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	remove, _ := args["remove"].(bool)
+
+	var keys []string
+	var cursor uint64
+	for {
+		page, next, err := s.store.Scan(ctx, cursor, titleKeyPrefix+"*", 0)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning title index: %v", err)
+		}
+		keys = append(keys, page...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	sort.Strings(keys)
+
+	var orphans []orphanedAlias
+	for _, key := range keys {
+		canonical, err := s.store.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			// Removed since the scan.
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading alias '%s': %v", key, err)
+		}
+		_, err = s.store.Get(ctx, canonical)
+		if errors.Is(err, ErrNotFound) {
+			orphans = append(orphans, orphanedAlias{key: key, canonical: canonical})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving content for key '%s': %v", canonical, err)
+		}
+	}
+
+	if len(orphans) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Checked %d title aliases, none are orphaned", len(keys))), nil
+	}
+
+	verb := "Found"
+	if remove {
+		verb = "Removed"
+		for _, orphan := range orphans {
+			if err := s.store.Delete(ctx, orphan.key); err != nil {
+				return nil, fmt.Errorf("error removing alias '%s': %v", orphan.key, err)
+			}
+		}
+	}
+
+	result := fmt.Sprintf("Checked %d title aliases. %s %d orphaned:\n", len(keys), verb, len(orphans))
+	for _, orphan := range orphans {
+		result += fmt.Sprintf("- %s -> %s (missing)\n", orphan.key, orphan.canonical)
+	}
+	return mcp.NewToolResultText(result), nil
+}
@@ -0,0 +1,180 @@
+// Package ratelimit caps how often each client may call tools.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ClientIDHeader identifies a client explicitly, for deployments where many
+// clients share an IP address behind a proxy. Clients choose its value, so
+// it is only believed from authenticated clients and trusted proxies.
+const ClientIDHeader = "X-Client-ID"
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket per client. Each bucket holds up to PerMinute
+// tokens and refills at PerMinute tokens a minute, so a client may burst up
+// to the full minute's allowance at once and is then held to the steady
+// rate.
+type Limiter struct {
+	perMinute int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New returns a Limiter allowing perMinute calls a minute per client. now
+// returns the current time and defaults to time.Now when nil.
+func New(perMinute int, now func() time.Time) *Limiter {
+	if now == nil {
+		now = time.Now
+	}
+	return &Limiter{
+		perMinute: perMinute,
+		now:       now,
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of client. When the bucket is empty it
+// returns false and how long until the next token is available.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(l.perMinute)
+	rate := capacity / time.Minute.Seconds()
+
+	b, ok := l.buckets[client]
+	if !ok {
+		l.prune(now)
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune forgets the buckets that have refilled completely, since a new
+// bucket starts full anyway. It keeps the map from growing with every client
+// ever seen.
+func (l *Limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+type clientKey struct{}
+
+// TrustedProxies are the addresses of the proxies whose X-Client-ID header
+// is believed for unauthenticated requests.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies splits a comma-separated TRUSTED_PROXIES value of IP
+// addresses and CIDR ranges, dropping blanks.
+func ParseTrustedProxies(raw string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES must list IP addresses or CIDR ranges, got '%s'", item)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// trusts reports whether the request came straight from a trusted proxy.
+func (p TrustedProxies) trusts(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithClient is a server.SSEContextFunc recording who sent a request: its
+// remote IP address, or the X-Client-ID header when the request was
+// authenticated by auth.Guard or came from one of p. Otherwise a client
+// could reset its allowance by sending a new header with every call.
+func (p TrustedProxies) WithClient(ctx context.Context, r *http.Request) context.Context {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+
+	client := host
+	if id := r.Header.Get(ClientIDHeader); id != "" && (auth.Authenticated(r.Context()) || p.trusts(host)) {
+		// Prefixed so a client ID cannot name, and use up, an IP
+		// address's bucket.
+		client = "id:" + id
+	}
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// clientFromContext returns the client recorded by WithClient, falling back
+// to the MCP session ID for transports without HTTP requests.
+func clientFromContext(ctx context.Context) string {
+	if client, ok := ctx.Value(clientKey{}).(string); ok {
+		return client
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// Middleware rejects tool calls from clients that have used up their
+// allowance with an error result explaining the limit, leaving the
+// connection open.
+func Middleware(l *Limiter) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if ok, wait := l.Allow(clientFromContext(ctx)); !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("rate limit exceeded: at most %d tool calls per minute, try again in %s", l.perMinute, wait.Round(time.Second))), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
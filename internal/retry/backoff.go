@@ -0,0 +1,95 @@
+// Package retry retries calls to the storage backends that fail with
+// transient errors, with exponential backoff between tries.
+package retry
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Jitter selects how a backoff delay is randomized. Randomizing delays keeps
+// many clients that failed together from retrying in lockstep.
+type Jitter int
+
+const (
+	// NoJitter waits the exact exponential delay.
+	NoJitter Jitter = iota
+	// FullJitter waits a uniformly random time between zero and the
+	// exponential delay.
+	FullJitter
+	// DecorrelatedJitter waits a random time between the base delay and
+	// three times the previous delay.
+	DecorrelatedJitter
+)
+
+// ParseJitter converts "none", "full" or "decorrelated" to a Jitter.
+func ParseJitter(s string) (Jitter, error) {
+	switch s {
+	case "", "none":
+		return NoJitter, nil
+	case "full":
+		return FullJitter, nil
+	case "decorrelated":
+		return DecorrelatedJitter, nil
+	default:
+		return NoJitter, fmt.Errorf("unknown jitter mode %q, want none, full or decorrelated", s)
+	}
+}
+
+// Backoff computes the delay before each retry attempt.
+type Backoff struct {
+	// Base is the delay before the first retry.
+	Base time.Duration
+	// Max caps every delay.
+	Max time.Duration
+	// Jitter randomizes the delays.
+	Jitter Jitter
+	// Rand returns a number in [0, 1). It defaults to math/rand.Float64 and
+	// exists so tests can make jitter deterministic.
+	Rand func() float64
+}
+
+// Delay returns how long to wait before retry attempt (starting at 1).
+// prev is the delay used before the previous attempt, which decorrelated
+// jitter builds on; it is ignored by the other modes.
+func (b Backoff) Delay(attempt int, prev time.Duration) time.Duration {
+	random := b.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+
+	switch b.Jitter {
+	case FullJitter:
+		return time.Duration(random() * float64(b.exponential(attempt)))
+	case DecorrelatedJitter:
+		if prev < b.Base {
+			prev = b.Base
+		}
+		d := b.Base + time.Duration(random()*float64(3*prev-b.Base))
+		return b.cap(d)
+	default:
+		return b.exponential(attempt)
+	}
+}
+
+func (b Backoff) exponential(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := b.Base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if b.Max > 0 && d >= b.Max {
+			return b.Max
+		}
+	}
+	return b.cap(d)
+}
+
+func (b Backoff) cap(d time.Duration) time.Duration {
+	if b.Max > 0 && d > b.Max {
+		return b.Max
+	}
+	return d
+}
@@ -0,0 +1,171 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/env"
+)
+
+// StatusError reports an HTTP response with a server error status, which
+// Transport turns into an error so it can be retried.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server error: %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// Transport is an http.RoundTripper that fails requests answered with a 5xx
+// status with a *StatusError, so clients that do not expose the status code,
+// such as the Upstash client, still surface it to Transient. Other responses
+// pass through unchanged.
+type Transport struct {
+	// Base makes the requests. It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(r)
+	if err != nil || resp.StatusCode < 500 {
+		return resp, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, &StatusError{Code: resp.StatusCode}
+}
+
+// CloseIdleConnections closes the idle connections of Base, so that
+// http.Client.CloseIdleConnections reaches through the wrapper.
+func (t *Transport) CloseIdleConnections() {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if closer, ok := base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Transient reports whether err is worth retrying: a 5xx response, a
+// network timeout or a dropped or refused connection. Everything else,
+// including validation errors, 4xx responses and a cancelled or expired
+// request context, fails at once.
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Policy decides how often and how patiently a failed call is retried.
+type Policy struct {
+	// Attempts is the total number of tries; 1 or less disables retries.
+	Attempts int
+	// Backoff spaces out the tries.
+	Backoff Backoff
+	// Sleep waits between tries, returning early with ctx.Err() when ctx
+	// ends. It defaults to a timer and exists so tests need not wait.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// PolicyFromEnv reads RETRY_ATTEMPTS (default 3), RETRY_BASE_DELAY
+// (default 100ms), RETRY_MAX_DELAY (default 2s) and RETRY_JITTER (none,
+// full or decorrelated; default full).
+func PolicyFromEnv() (Policy, error) {
+	attempts, err := env.Int("RETRY_ATTEMPTS", 3)
+	if err != nil {
+		return Policy{}, err
+	}
+	if attempts < 1 {
+		return Policy{}, fmt.Errorf("RETRY_ATTEMPTS must be at least 1, got %d", attempts)
+	}
+	base, err := env.Duration("RETRY_BASE_DELAY", 100*time.Millisecond)
+	if err != nil {
+		return Policy{}, err
+	}
+	maxDelay, err := env.Duration("RETRY_MAX_DELAY", 2*time.Second)
+	if err != nil {
+		return Policy{}, err
+	}
+	raw := os.Getenv("RETRY_JITTER")
+	if raw == "" {
+		raw = "full"
+	}
+	jitter, err := ParseJitter(raw)
+	if err != nil {
+		return Policy{}, fmt.Errorf("RETRY_JITTER: %v", err)
+	}
+	return Policy{
+		Attempts: attempts,
+		Backoff:  Backoff{Base: base, Max: maxDelay, Jitter: jitter},
+	}, nil
+}
+
+// Do calls fn until it succeeds, fails with an error that is not Transient,
+// or has been tried p.Attempts times, waiting out the backoff in between.
+// It returns fn's last error.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	sleep := p.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	var prev time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !Transient(err) {
+			return err
+		}
+		prev = p.Backoff.Delay(attempt, prev)
+		if sleep(ctx, prev) != nil {
+			return err
+		}
+	}
+}
+
+// Value is Do for calls that also return a result.
+func Value[T any](ctx context.Context, p Policy, fn func() (T, error)) (T, error) {
+	var out T
+	err := p.Do(ctx, func() error {
+		var err error
+		out, err = fn()
+		return err
+	})
+	return out, err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,24 @@
+// Package sanitize cleans text written by clients before it is stored.
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Text returns s without its control characters, such as NUL or ANSI escape
+// introducers, which break display and some stores. Newlines, carriage
+// returns and tabs are kept, as are all printable characters; a byte that
+// is not valid UTF-8 becomes U+FFFD.
+func Text(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return r
+		case unicode.IsControl(r):
+			return -1
+		default:
+			return r
+		}
+	}, s)
+}
@@ -0,0 +1,82 @@
+package serverinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Capabilities is the part of the initialize handshake reported by the
+// capabilities tool.
+type Capabilities struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	ServerInfo      mcp.Implementation     `json:"serverInfo"`
+	Capabilities    mcp.ServerCapabilities `json:"capabilities"`
+}
+
+// CapabilitiesTool returns a tool reporting the capabilities the serving
+// MCP server advertises during initialize, so clients can inspect them at
+// any time.
+func CapabilitiesTool() server.ServerTool {
+	tool := mcp.NewTool("capabilities",
+		mcp.WithDescription("Return the server's advertised capabilities and protocol version as JSON"),
+	)
+
+	return server.ServerTool{Tool: tool, Handler: handleCapabilities}
+}
+
+func handleCapabilities(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil, fmt.Errorf("no MCP server in request context")
+	}
+
+	caps, err := Describe(srv)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(caps, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding capabilities: %v", err)
+	}
+
+	return mcp.NewToolResultText(string(out)), nil
+}
+
+// Describe replays an initialize request against srv and returns what it
+// would advertise to a client speaking the latest protocol version. The
+// request carries no client session, so it has no side effects.
+func Describe(srv *server.MCPServer) (Capabilities, error) {
+	initialize, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(0),
+		Request: mcp.Request{Method: string(mcp.MethodInitialize)},
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			ClientInfo:      mcp.Implementation{Name: "capabilities", Version: "1.0.0"},
+		},
+	})
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("error encoding initialize request: %v", err)
+	}
+
+	response, ok := srv.HandleMessage(context.Background(), initialize).(mcp.JSONRPCResponse)
+	if !ok {
+		return Capabilities{}, fmt.Errorf("server rejected initialize request")
+	}
+
+	result, ok := response.Result.(mcp.InitializeResult)
+	if !ok {
+		return Capabilities{}, fmt.Errorf("unexpected initialize result type %T", response.Result)
+	}
+
+	return Capabilities{
+		ProtocolVersion: result.ProtocolVersion,
+		ServerInfo:      result.ServerInfo,
+		Capabilities:    result.Capabilities,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package serverinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Info is the JSON body of the /info endpoint.
+type Info struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Transport string   `json:"transport"`
+	Store     string   `json:"store"`
+	Tools     []string `json:"tools"`
+}
+
+// Path is where Register serves the server description.
+const Path = "/info"
+
+// Register adds /info to mux, describing srv for orchestration tooling:
+// its name and version, the transport it is served over, the store behind
+// its tools and the names of the tools registered on it when asked.
+func Register(mux *http.ServeMux, srv *server.MCPServer, transport, store string) {
+	mux.HandleFunc("GET "+Path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		caps, err := Describe(srv)
+		var tools []string
+		if err == nil {
+			tools, err = ToolNames(srv)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		json.NewEncoder(w).Encode(Info{
+			Name:      caps.ServerInfo.Name,
+			Version:   caps.ServerInfo.Version,
+			Transport: transport,
+			Store:     store,
+			Tools:     tools,
+		})
+	})
+}
+
+// ToolNames lists the tools registered on srv, sorted by name, by replaying
+// a tools/list request the way Describe replays initialize.
+func ToolNames(srv *server.MCPServer) ([]string, error) {
+	list, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(0),
+		Request: mcp.Request{Method: string(mcp.MethodToolsList)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding tools/list request: %v", err)
+	}
+
+	response, ok := srv.HandleMessage(context.Background(), list).(mcp.JSONRPCResponse)
+	if !ok {
+		return nil, fmt.Errorf("server rejected tools/list request")
+	}
+
+	result, ok := response.Result.(mcp.ListToolsResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tools/list result type %T", response.Result)
+	}
+
+	names := make([]string, len(result.Tools))
+	for i, tool := range result.Tools {
+		names[i] = tool.Name
+	}
+	return names, nil
+}
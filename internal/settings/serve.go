@@ -0,0 +1,161 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/config"
+	"github.com/MikeLuu99/go-mcp/internal/cors"
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/health"
+	"github.com/MikeLuu99/go-mcp/internal/lifecycle"
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/MikeLuu99/go-mcp/internal/metrics"
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Server describes one server binary: its name and the stores and tools
+// it serves. Serve supplies everything else.
+type Server struct {
+	Name    string
+	Version string
+	// Port is listened on unless PORT is set.
+	Port int
+	// Store names the backing store /info reports.
+	Store string
+	// Options are added to the MCP server options every server gets, such
+	// as resource capabilities.
+	Options []server.ServerOption
+	// Open reads the server's own settings and opens its stores. Its error
+	// is reported together with those of the shared settings.
+	Open func() (*Backend, error)
+}
+
+// Backend is what a server serves once its stores are open.
+type Backend struct {
+	// Check, when set, runs once within STARTUP_TIMEOUT before the server
+	// accepts requests, so that wrong credentials stop it at startup.
+	Check func(ctx context.Context) error
+	// Ping answers the readiness probe.
+	Ping func(ctx context.Context) error
+	// Register adds the tools, prompts and resources to s, wrapping the
+	// stores in the retry policy.
+	Register func(s *server.MCPServer, hooks *server.Hooks, policy retry.Policy)
+	// Close releases the stores on shutdown.
+	Close func() error
+}
+
+// Serve runs srv until SIGINT or SIGTERM. It loads .env and the --config
+// file, reads every setting, checks the stores and serves the tools over
+// the configured transport, behind auth and CORS and beside the probes,
+// metrics and info endpoints. An invalid setting or a failed startup check
+// exits the process.
+func Serve(srv Server) {
+	started := time.Now()
+	configPath := flag.String("config", "", "YAML or JSON config file; environment variables override its settings")
+	flag.Parse()
+
+	// Without a .env file the settings come from the environment alone.
+	if err := env.LoadFile(".env"); err != nil {
+		log.Fatalf("Error loading .env file: %v\n", err)
+	}
+	if err := config.Apply(*configPath); err != nil {
+		log.Fatalf("Invalid configuration: %v\n", err)
+	}
+	// Every setting is read before any is used, so that all the invalid
+	// ones are reported together rather than one per restart.
+	shared, sharedErr := FromEnv(srv.Port)
+	backend, backendErr := srv.Open()
+	if err := errors.Join(sharedErr, backendErr); err != nil {
+		log.Fatalf("Invalid configuration:\n%v\n", err)
+	}
+
+	logger := logging.New(os.Stderr, shared.LogLevel)
+	slog.SetDefault(logger)
+
+	if backend.Check != nil {
+		checkCtx, cancelCheck := context.WithTimeout(context.Background(), shared.StartupTimeout)
+		err := backend.Check(checkCtx)
+		cancelCheck()
+		if err != nil {
+			log.Fatalf("Startup check failed: %v\n", err)
+		}
+	}
+
+	// A token file can be reloaded without a restart, on SIGHUP or with the
+	// reload-auth tool.
+	if shared.AuthTokens != nil && shared.AuthTokens.Reloadable() {
+		shared.AuthTokens.ReloadOnSignal(context.Background())
+	}
+
+	httpServer, mcpServer, err := shared.Build(srv, backend, logger, started)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v\n", err)
+	}
+
+	fmt.Printf("Starting %s server on port: %d\n", shared.Transport, shared.Port)
+	for _, endpoint := range transport.Endpoints(mcpServer) {
+		fmt.Println(endpoint)
+	}
+	fmt.Printf("Health Endpoints: %s, %s\n", shared.HealthPaths.Health, shared.HealthPaths.Ready)
+	fmt.Printf("Metrics Endpoint: %s\n", shared.MetricsPath)
+	fmt.Printf("Info Endpoint: %s\n", serverinfo.Path)
+	if shared.AuthTokens == nil {
+		fmt.Println("MCP_AUTH_TOKEN and MCP_AUTH_TOKENS_FILE are not set, MCP endpoints accept unauthenticated requests")
+	}
+
+	if err := lifecycle.Serve(httpServer, mcpServer, shared.TLS, shared.ShutdownTimeout, backend.Close); err != nil {
+		log.Fatalf("Server error: %v\n", err)
+	}
+}
+
+// Build builds the MCP server of srv over backend and the HTTP server
+// serving it on the configured port and transport, reporting uptime from
+// started. It does not start listening.
+func (s *Shared) Build(srv Server, backend *Backend, logger *slog.Logger, started time.Time) (*http.Server, transport.Server, error) {
+	toolMetrics := metrics.New()
+	hooks := &server.Hooks{}
+	serverOpts := append([]server.ServerOption{
+		server.WithToolCapabilities(true),
+		server.WithHooks(hooks),
+	}, srv.Options...)
+	serverOpts = append(serverOpts, s.ServerOptions(logger, toolMetrics)...)
+	ms := server.NewMCPServer(srv.Name, srv.Version, serverOpts...)
+
+	backend.Register(ms, hooks, s.Retry)
+	ms.AddTools(serverinfo.CapabilitiesTool())
+	if s.AuthTokens != nil && s.AuthTokens.Reloadable() {
+		ms.AddTools(s.AuthTokens.ReloadTool())
+	}
+
+	httpServer := &http.Server{
+		Addr: fmt.Sprintf(":%d", s.Port),
+	}
+	mcpServer, err := transport.New(s.Transport, ms, httpServer, s.TrustedProxies)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := http.NewServeMux()
+	// The operational endpoints are registered before the transport's "/"
+	// catch-all, and FromEnv keeps their paths clear of its routes.
+	health.Register(mux, s.HealthPaths, srv.Version, started, backend.Ping, s.ReadyTimeout)
+	toolMetrics.Register(mux, s.MetricsPath)
+	serverinfo.Register(mux, ms, s.Transport, srv.Store)
+	mux.Handle(transport.Pattern(mcpServer), auth.Guard(s.AuthTokens, mcpServer))
+	// CORS wraps everything so preflights are answered before auth.
+	httpServer.Handler = cors.Handler(s.AllowedOrigins, mux)
+
+	return httpServer, mcpServer, nil
+}
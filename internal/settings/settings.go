@@ -0,0 +1,186 @@
+// Package settings reads the settings every server shares from the
+// environment, builds the tool middleware they configure and serves the
+// tools, so the servers differ only in their stores and tools.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/cors"
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/health"
+	"github.com/MikeLuu99/go-mcp/internal/lifecycle"
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/MikeLuu99/go-mcp/internal/metrics"
+	"github.com/MikeLuu99/go-mcp/internal/middleware"
+	"github.com/MikeLuu99/go-mcp/internal/ratelimit"
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/MikeLuu99/go-mcp/internal/timeout"
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Shared holds the settings common to every server.
+type Shared struct {
+	Port           int
+	Transport      string
+	TrustedProxies ratelimit.TrustedProxies
+	TLS            lifecycle.TLSFiles
+	// AuthTokens is nil when neither MCP_AUTH_TOKEN nor
+	// MCP_AUTH_TOKENS_FILE is set.
+	AuthTokens     *auth.Tokens
+	AllowedOrigins []string
+	LogLevel       slog.Level
+	Retry          retry.Policy
+
+	// HealthPaths and MetricsPath are where the probes and the metrics are
+	// served, next to the MCP transport on the same mux.
+	HealthPaths health.Paths
+	MetricsPath string
+
+	ShutdownTimeout time.Duration
+	ReadyTimeout    time.Duration
+	StartupTimeout  time.Duration
+	// ToolTimeout is the deadline of every tool call, 0 for none.
+	ToolTimeout time.Duration
+	// RateLimit is the tool calls allowed per client a minute, 0 for no
+	// limit.
+	RateLimit int
+}
+
+// FromEnv reads the shared settings, listening on defaultPort unless PORT
+// is set. It reports all of the invalid settings together, one per line.
+func FromEnv(defaultPort int) (*Shared, error) {
+	var s Shared
+	var errs []error
+	var err error
+
+	s.Port, err = env.Port("PORT", defaultPort)
+	errs = append(errs, err)
+	s.Transport, err = transport.FromEnv()
+	errs = append(errs, err)
+	s.TrustedProxies, err = ratelimit.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	errs = append(errs, err)
+	s.TLS, err = lifecycle.TLSFromEnv()
+	errs = append(errs, err)
+	s.AuthTokens, err = auth.FromEnv()
+	errs = append(errs, err)
+	s.AllowedOrigins = cors.ParseOrigins(os.Getenv("ALLOWED_ORIGINS"))
+	s.LogLevel, err = logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	errs = append(errs, err)
+	s.Retry, err = retry.PolicyFromEnv()
+	errs = append(errs, err)
+
+	s.HealthPaths.Health, err = endpointPath("HEALTH_PATH", health.DefaultPaths.Health)
+	errs = append(errs, err)
+	s.HealthPaths.Ready, err = endpointPath("READY_PATH", health.DefaultPaths.Ready)
+	errs = append(errs, err)
+	s.MetricsPath, err = endpointPath("METRICS_PATH", metrics.DefaultPath)
+	errs = append(errs, err)
+	errs = append(errs, s.checkPaths()...)
+
+	s.ShutdownTimeout, err = env.Duration("SHUTDOWN_TIMEOUT", 10*time.Second)
+	errs = append(errs, err)
+	s.ReadyTimeout, err = env.Duration("READY_TIMEOUT", 2*time.Second)
+	errs = append(errs, err)
+	s.StartupTimeout, err = env.Duration("STARTUP_TIMEOUT", 10*time.Second)
+	errs = append(errs, err)
+
+	toolTimeout, err := env.Int("TOOL_TIMEOUT_MS", 30000)
+	errs = append(errs, err)
+	if toolTimeout < 0 {
+		errs = append(errs, fmt.Errorf("TOOL_TIMEOUT_MS must not be negative, got %d", toolTimeout))
+	}
+	s.ToolTimeout = time.Duration(toolTimeout) * time.Millisecond
+
+	s.RateLimit, err = env.Int("RATE_LIMIT_PER_MIN", 0)
+	errs = append(errs, err)
+	if s.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_PER_MIN must not be negative, got %d", s.RateLimit))
+	}
+
+	// errors.Join drops the nil errors of the settings that were valid.
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// endpointPath reads the path of an operational endpoint from the
+// environment variable name, defaulting to def.
+func endpointPath(name, def string) (string, error) {
+	path := os.Getenv(name)
+	if path == "" {
+		return def, nil
+	}
+	// Braces would turn the path into a mux wildcard.
+	if !strings.HasPrefix(path, "/") || path == "/" || strings.ContainsAny(path, " \t{}") {
+		return "", fmt.Errorf("%s must be a path starting with / other than / itself, got '%s'", name, path)
+	}
+	return path, nil
+}
+
+// checkPaths reports the operational endpoints that share a path with each
+// other, /info or the MCP transport, which the mux would either refuse or
+// let one of them shadow.
+func (s *Shared) checkPaths() []error {
+	taken := map[string]string{serverinfo.Path: "the info endpoint"}
+	for _, path := range transport.Paths {
+		taken[path] = "the MCP transport"
+	}
+
+	var errs []error
+	for _, p := range []struct{ name, path string }{
+		{"HEALTH_PATH", s.HealthPaths.Health},
+		{"READY_PATH", s.HealthPaths.Ready},
+		{"METRICS_PATH", s.MetricsPath},
+	} {
+		if p.path == "" {
+			// Invalid, and already reported.
+			continue
+		}
+		if owner, exists := taken[p.path]; exists {
+			errs = append(errs, fmt.Errorf("%s '%s' is already used by %s", p.name, p.path, owner))
+			continue
+		}
+		taken[p.path] = p.name
+	}
+	return errs
+}
+
+// Middleware returns the tool middleware the settings call for, outermost
+// first, logging to logger and recording into toolMetrics.
+func (s *Shared) Middleware(logger *slog.Logger, toolMetrics *metrics.Metrics) []server.ToolHandlerMiddleware {
+	// Logging and metrics come first so rate-limited calls are recorded too.
+	mws := []server.ToolHandlerMiddleware{
+		logging.Middleware(logger),
+		toolMetrics.Middleware(),
+	}
+	if s.RateLimit > 0 {
+		mws = append(mws, ratelimit.Middleware(ratelimit.New(s.RateLimit, nil)))
+	}
+	if s.ToolTimeout > 0 {
+		mws = append(mws, timeout.Middleware(s.ToolTimeout))
+	}
+	// Recovery goes innermost: the timeout middleware runs the handler on its
+	// own goroutine, and only that goroutine can recover the handler's panic.
+	return append(mws, middleware.Recover(logger))
+}
+
+// ServerOptions returns the option installing Middleware on every tool of
+// the server.
+func (s *Shared) ServerOptions(logger *slog.Logger, toolMetrics *metrics.Metrics) []server.ServerOption {
+	mws := s.Middleware(logger, toolMetrics)
+	return []server.ServerOption{
+		server.WithToolHandlerMiddleware(func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return middleware.Chain(next, mws...)
+		}),
+	}
+}
@@ -0,0 +1,54 @@
+// Package timeout bounds how long a tool call may run.
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type outcome struct {
+	result *mcp.CallToolResult
+	err    error
+}
+
+// Middleware gives every tool call a context that expires after d and
+// passes it to the handler, so storage calls made with it are cancelled
+// when the deadline passes. A call that has not returned by then is
+// answered with an error result saying it timed out, even if the handler
+// ignores its context; the handler's eventual outcome is discarded.
+func Middleware(d time.Duration) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			done := make(chan outcome, 1)
+			go func() {
+				result, err := next(ctx, request)
+				done <- outcome{result, err}
+			}()
+
+			select {
+			case o := <-done:
+				if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return timedOut(request, d), nil
+				}
+				return o.result, o.err
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return timedOut(request, d), nil
+				}
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+func timedOut(request mcp.CallToolRequest, d time.Duration) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("tool '%s' timed out after %s, the storage backend is responding slowly", request.Params.Name, d))
+}
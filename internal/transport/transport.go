@@ -0,0 +1,104 @@
+// Package transport builds the HTTP transport the MCP servers are served
+// over, chosen with MCP_TRANSPORT.
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/MikeLuu99/go-mcp/internal/ratelimit"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The transports MCP_TRANSPORT selects between.
+const (
+	SSE            = "sse"
+	StreamableHTTP = "streamable-http"
+)
+
+// The paths the transports serve clients on.
+const (
+	SSEPath            = "/mcp/sse"
+	MessagePath        = "/mcp/message"
+	StreamableHTTPPath = "/mcp"
+)
+
+// Paths lists every path a transport serves. The SSE server is mounted on
+// "/" and routes these itself, so other handlers on the same mux must stay
+// clear of them.
+var Paths = []string{SSEPath, MessagePath, StreamableHTTPPath}
+
+// Server is an MCP transport, mounted on the mux of the shared HTTP server
+// and closed by lifecycle.Serve on shutdown.
+type Server interface {
+	http.Handler
+	Shutdown(ctx context.Context) error
+}
+
+// FromEnv reads MCP_TRANSPORT, which defaults to SSE.
+func FromEnv() (string, error) {
+	kind := os.Getenv("MCP_TRANSPORT")
+	switch kind {
+	case "":
+		return SSE, nil
+	case SSE, StreamableHTTP:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("MCP_TRANSPORT must be '%s' or '%s', got '%s'", SSE, StreamableHTTP, kind)
+	}
+}
+
+// New creates the kind of transport serving s, attributing each request to
+// its client for rate limiting, with the X-Client-ID header believed from
+// the trusted proxies. httpServer is the server it is mounted on, which
+// Shutdown stops.
+func New(kind string, s *server.MCPServer, httpServer *http.Server, trusted ratelimit.TrustedProxies) (Server, error) {
+	switch kind {
+	case SSE:
+		return server.NewSSEServer(
+			s,
+			server.WithStaticBasePath("/"),
+			server.WithSSEEndpoint(SSEPath),
+			server.WithMessageEndpoint(MessagePath),
+			server.WithHTTPServer(httpServer),
+			server.WithSSEContextFunc(trusted.WithClient),
+		), nil
+	case StreamableHTTP:
+		return server.NewStreamableHTTPServer(
+			s,
+			server.WithEndpointPath(StreamableHTTPPath),
+			server.WithStreamableHTTPServer(httpServer),
+			server.WithHTTPContextFunc(trusted.WithClient),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown transport '%s'", kind)
+	}
+}
+
+// Pattern returns the mux pattern srv is mounted under. The SSE server
+// routes its own endpoints, while the Streamable HTTP server answers
+// whatever path it is given.
+func Pattern(srv Server) string {
+	if _, ok := srv.(*server.StreamableHTTPServer); ok {
+		return StreamableHTTPPath
+	}
+	return "/"
+}
+
+// Endpoints describes the paths clients connect to on srv, one line each,
+// for the startup banner.
+func Endpoints(srv Server) []string {
+	switch srv := srv.(type) {
+	case *server.SSEServer:
+		return []string{
+			fmt.Sprintf("SSE Endpoint: %s", srv.CompleteSsePath()),
+			fmt.Sprintf("Message Endpoint: %s", srv.CompleteMessagePath()),
+		}
+	case *server.StreamableHTTPServer:
+		return []string{fmt.Sprintf("Streamable HTTP Endpoint: %s", StreamableHTTPPath)}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestBearerAuth(t *testing.T) {
+	// The handler streams an event and flushes it, like the SSE endpoint.
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: endpoint\ndata: /mcp/message\n\n"))
+		w.(http.Flusher).Flush()
+	})
+
+	srv := httptest.NewServer(auth.Bearer("s3cret", stream))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{name: "valid token", header: "Bearer s3cret", status: http.StatusOK},
+		{name: "missing header", status: http.StatusUnauthorized},
+		{name: "wrong token", header: "Bearer guess", status: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic s3cret", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/mcp/sse", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Got status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			line, err := bufio.NewReader(resp.Body).ReadString('\n')
+			if err != nil || !strings.HasPrefix(line, "event: endpoint") {
+				t.Errorf("Expected the event stream to pass through, got %q (%v)", line, err)
+			}
+		})
+	}
+}
+
+func TestBearerAuthDisabled(t *testing.T) {
+	srv := httptest.NewServer(auth.Bearer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Got status %d without a configured token, want 200", resp.StatusCode)
+	}
+}
+
+func TestReloadAuthTokens(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens")
+	writeTokens := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTokens("# rotated monthly\nold-token\n")
+
+	tokens, err := auth.LoadTokens(path)
+	if err != nil {
+		t.Fatal("LoadTokens:", err)
+	}
+	web := httptest.NewServer(auth.Guard(tokens, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	defer web.Close()
+
+	status := func(token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, web.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := status("old-token"); got != http.StatusOK {
+		t.Fatalf("Got status %d for the initial token, want 200", got)
+	}
+
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddTools(tokens.ReloadTool())
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	admin := mcptest.NewUnstartedServer(t)
+	admin.AddTools(withGrant(auth.Grant{Admin: true}, tokens.ReloadTool()))
+	if err := admin.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	writeTokens("new-token\nsecond-token\n")
+	if _, err := callTool(ctx, srv.Client(), "reload-auth", nil); err == nil {
+		t.Fatal("Expected reload-auth without an admin token to be refused")
+	}
+	if got := status("old-token"); got != http.StatusOK {
+		t.Fatalf("Got status %d after a refused reload, want 200", got)
+	}
+
+	got, err := callTool(ctx, admin.Client(), "reload-auth", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "Reloaded 2 auth tokens" {
+		t.Errorf("Got %q", got)
+	}
+	if got := status("old-token"); got != http.StatusUnauthorized {
+		t.Errorf("Got status %d for the rotated-out token, want 401", got)
+	}
+	if got := status("new-token"); got != http.StatusOK {
+		t.Errorf("Got status %d for the new token, want 200", got)
+	}
+
+	// A file emptied by mistake is refused and the current tokens stay.
+	writeTokens("\n")
+	if _, err := callTool(ctx, admin.Client(), "reload-auth", nil); err == nil {
+		t.Error("Expected an empty token file to be refused")
+	}
+	if got := status("new-token"); got != http.StatusOK {
+		t.Errorf("Got status %d after a failed reload, want 200", got)
+	}
+
+	sigCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tokens.ReloadOnSignal(sigCtx)
+	writeTokens("hup-token\n")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for status("hup-token") != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("SIGHUP did not reload the tokens")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := status("new-token"); got != http.StatusUnauthorized {
+		t.Errorf("Got status %d for a token removed by SIGHUP, want 401", got)
+	}
+}
+
+func TestReloadAuthNamespaces(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens")
+	writeTokens := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTokens("alice-token  alice\nops-token  -  admin\n")
+
+	tokens, err := auth.LoadTokens(path)
+	if err != nil {
+		t.Fatal("LoadTokens:", err)
+	}
+
+	// Each call is made with the token in caller, looked up in the current
+	// map as Guard does for every request.
+	var caller string
+	asCaller := func(tool server.ServerTool) server.ServerTool {
+		next := tool.Handler
+		tool.Handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			grant, ok := tokens.Lookup(caller)
+			if !ok {
+				return nil, fmt.Errorf("unauthorized")
+			}
+			return next(auth.WithGrant(ctx, grant), request)
+		}
+		return tool
+	}
+
+	index := NewMockVectorIndex()
+	srv := mcptest.NewUnstartedServer(t)
+	for _, tool := range memory.NewService(index, memory.DefaultConfig()).Tools() {
+		srv.AddTools(asCaller(tool))
+	}
+	srv.AddTools(asCaller(tokens.ReloadTool()))
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := srv.Client()
+
+	caller = "alice-token"
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "a1", "content": "first memory"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "b1", "content": "elsewhere", "namespace": "bob"}); err == nil {
+		t.Error("Expected a confined token to be refused another namespace")
+	}
+	if _, err := callTool(ctx, client, "reload-auth", nil); err == nil {
+		t.Error("Expected reload-auth to need the admin role")
+	}
+
+	// Rotate alice's token and move her to a new namespace.
+	writeTokens("alice-token-2  alice-2\nops-token  -  admin\n")
+	caller = "ops-token"
+	if got, err := callTool(ctx, client, "reload-auth", nil); err != nil || got != "Reloaded 2 auth tokens" {
+		t.Fatalf("Got %q, %v from reload-auth", got, err)
+	}
+
+	caller = "alice-token"
+	if _, err := callTool(ctx, client, "get-memory", map[string]any{"id": "a1"}); err == nil {
+		t.Error("Expected the rotated-out token to be rejected")
+	}
+
+	caller = "alice-token-2"
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "a2", "content": "second memory"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if _, exists := index.namespaces["alice-2"].data["a2"]; !exists {
+		t.Error("Expected the new token's memory in its mapped namespace")
+	}
+	if _, exists := index.data["a2"]; exists {
+		t.Error("Expected nothing written to the default namespace")
+	}
+	if _, exists := index.namespaces["alice"].data["a1"]; !exists {
+		t.Error("Expected the old token's memory in its namespace")
+	}
+
+	// Tools without a namespace argument are confined too.
+	got, err := callTool(ctx, client, "tag-usage-stats", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "1 memories") {
+		t.Errorf("Expected only the mapped namespace counted, got %q", got)
+	}
+}
+
+func TestLoadTokensRejectsMalformedLines(t *testing.T) {
+	for name, contents := range map[string]string{
+		"unknown role":   "token ns owner\n",
+		"extra field":    "token ns admin extra\n",
+		"repeated token": "token a\ntoken b\n",
+	} {
+		path := filepath.Join(t.TempDir(), "tokens")
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := auth.LoadTokens(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// withGrant returns tool with its handler called as a request authenticated
+// with grant.
+func withGrant(grant auth.Grant, tool server.ServerTool) server.ServerTool {
+	next := tool.Handler
+	tool.Handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(auth.WithGrant(ctx, grant), request)
+	}
+	return tool
+}
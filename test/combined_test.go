@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/MikeLuu99/go-mcp/internal/papers"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+)
+
+func TestCombinedServer(t *testing.T) {
+	ctx := context.Background()
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddTools(memory.NewService(NewMockVectorIndex(), memory.DefaultConfig()).Tools()...)
+	srv.AddTools(papers.NewService(NewMockRedisClient(), papers.DefaultConfig()).Tools()...)
+	srv.AddTools(serverinfo.CapabilitiesTool())
+	defer srv.Close()
+
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	// A name shared by both tool sets would silently shadow one of them.
+	listed, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal("ListTools:", err)
+	}
+	want := len(memory.NewService(NewMockVectorIndex(), memory.DefaultConfig()).Tools()) +
+		len(papers.NewService(NewMockRedisClient(), papers.DefaultConfig()).Tools()) + 1
+	if len(listed.Tools) != want {
+		t.Errorf("Got %d tools, want %d", len(listed.Tools), want)
+	}
+
+	got, err := callTool(ctx, client, "add-to-memory", map[string]any{
+		"id":      "combined",
+		"content": "Served next to the research papers",
+	})
+	if err != nil {
+		t.Fatal("add-to-memory:", err)
+	}
+	if !strings.Contains(got, "combined") {
+		t.Errorf("Got %q, want the stored memory ID", got)
+	}
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "combined"})
+	if err != nil {
+		t.Fatal("get-memory:", err)
+	}
+	if !strings.Contains(got, "Served next to the research papers") {
+		t.Errorf("Got %q, want the stored content", got)
+	}
+
+	got, err = callTool(ctx, client, "set-new-research-paper", map[string]any{
+		"title":         "Combined Servers",
+		"summarization": "One port for every tool",
+	})
+	if err != nil {
+		t.Fatal("set-new-research-paper:", err)
+	}
+	if got != "Successful update of the knowledge base" {
+		t.Errorf("Got %q, want %q", got, "Successful update of the knowledge base")
+	}
+	got, err = callTool(ctx, client, "get-research-paper", map[string]any{"title": "Combined Servers"})
+	if err != nil {
+		t.Fatal("get-research-paper:", err)
+	}
+	if !strings.Contains(got, "One port for every tool") {
+		t.Errorf("Got %q, want the stored summarization", got)
+	}
+}
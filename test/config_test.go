@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/config"
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+)
+
+// configVars are the variables a config file can set, cleared by the
+// tests so that t.Setenv restores whatever config.Apply writes.
+var configVars = []string{
+	"PORT", "MCP_TRANSPORT", "VECTOR_DB_URL", "TOKEN", "REDIS_URL", "REDIS_KEY_PREFIX",
+	"UPSTASH_MAX_IDLE_CONNS", "SHUTDOWN_TIMEOUT", "READY_TIMEOUT", "STARTUP_TIMEOUT",
+	"TOOL_TIMEOUT_MS", "RATE_LIMIT_PER_MIN", "MIN_CONTENT_LENGTH", "MAX_CONTENT_BYTES",
+}
+
+func writeConfig(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFile(t *testing.T) {
+	for _, key := range configVars {
+		t.Setenv(key, "")
+	}
+	// Set in the environment, so it wins over the file.
+	t.Setenv("PORT", "7000")
+
+	path := writeConfig(t, "config.yaml", `
+port: 9999
+transport: streamable-http
+upstash:
+  url: https://example-vector.upstash.io
+  token: secret
+  max_idle_conns: 4
+redis:
+  url: redis://localhost:6379
+  key_prefix: "papers:"
+timeouts:
+  shutdown: 20s
+  ready: 1s
+  tool: 5s
+limits:
+  rate_limit_per_min: 60
+  max_content_bytes: 1024
+`)
+	if err := config.Apply(path); err != nil {
+		t.Fatal("Apply:", err)
+	}
+
+	if port, err := env.Port("PORT", 9090); err != nil || port != 7000 {
+		t.Errorf("Expected the environment's port 7000, got %d (%v)", port, err)
+	}
+	if kind, err := transport.FromEnv(); err != nil || kind != transport.StreamableHTTP {
+		t.Errorf("Expected transport %s, got %q (%v)", transport.StreamableHTTP, kind, err)
+	}
+	if _, err := memory.NewIndexFromEnv(memory.NewHTTPClient(1)); err != nil {
+		t.Errorf("Expected the Upstash credentials from the file, got: %v", err)
+	}
+	for key, want := range map[string]string{
+		"REDIS_URL":              "redis://localhost:6379",
+		"REDIS_KEY_PREFIX":       "papers:",
+		"UPSTASH_MAX_IDLE_CONNS": "4",
+		"TOOL_TIMEOUT_MS":        "5000",
+		"RATE_LIMIT_PER_MIN":     "60",
+		"STARTUP_TIMEOUT":        "",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s: got %q, want %q", key, got, want)
+		}
+	}
+	if shutdown, err := env.Duration("SHUTDOWN_TIMEOUT", 10*time.Second); err != nil || shutdown != 20*time.Second {
+		t.Errorf("Expected shutdown timeout 20s, got %v (%v)", shutdown, err)
+	}
+	cfg, err := memory.ConfigFromEnv()
+	if err != nil {
+		t.Fatal("ConfigFromEnv:", err)
+	}
+	if cfg.MaxContentBytes != 1024 {
+		t.Errorf("Expected max content bytes 1024, got %d", cfg.MaxContentBytes)
+	}
+}
+
+func TestConfigFileJSON(t *testing.T) {
+	path := writeConfig(t, "config.json", `{"port": 8181, "limits": {"min_content_length": 3}}`)
+	f, err := config.Load(path)
+	if err != nil {
+		t.Fatal("Load:", err)
+	}
+	vars := f.Env()
+	if vars["PORT"] != "8181" || vars["MIN_CONTENT_LENGTH"] != "3" || len(vars) != 2 {
+		t.Errorf("Unexpected settings: %v", vars)
+	}
+}
+
+func TestConfigFileInvalid(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `
+port: 70000
+transport: websocket
+timeouts:
+  ready: soon
+  tool: 500us
+limits:
+  rate_limit_per_min: -1
+`)
+	_, err := config.Load(path)
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	for _, want := range []string{"port", "transport", "timeouts.ready", "timeouts.tool", "limits.rate_limit_per_min"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %s, got: %v", want, err)
+		}
+	}
+
+	path = writeConfig(t, "config.yaml", "prot: 8080\n")
+	if _, err := config.Load(path); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+}
+
+func TestConfigFromEnvReportsAll(t *testing.T) {
+	t.Setenv("MIN_CONTENT_LENGTH", "short")
+	t.Setenv("MMR_LAMBDA", "high")
+	t.Setenv("STRICT_TOP_K", "maybe")
+	_, err := memory.ConfigFromEnv()
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	for _, want := range []string{"MIN_CONTENT_LENGTH", "MMR_LAMBDA", "STRICT_TOP_K"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %s, got: %v", want, err)
+		}
+	}
+}
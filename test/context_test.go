@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/MikeLuu99/go-mcp/internal/papers"
+	"github.com/MikeLuu99/go-mcp/internal/timeout"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/upstash/vector-go"
+)
+
+// blockingVectorIndex blocks searches until the request context ends and
+// reports the context error it observed.
+type blockingVectorIndex struct {
+	*MockVectorIndex
+	observed chan error
+}
+
+func (b *blockingVectorIndex) QueryData(ctx context.Context, q vector.QueryData) ([]vector.VectorScore, error) {
+	<-ctx.Done()
+	b.observed <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// blockingStore blocks reads until the request context ends and reports
+// the context error it observed.
+type blockingStore struct {
+	*MockRedisClient
+	observed chan error
+}
+
+func (b *blockingStore) Get(ctx context.Context, key string) (string, error) {
+	<-ctx.Done()
+	b.observed <- ctx.Err()
+	return "", ctx.Err()
+}
+
+// slowStore takes delay to answer every read, ignoring the request
+// context the way a hung connection would.
+type slowStore struct {
+	*MockRedisClient
+	delay time.Duration
+}
+
+func (s *slowStore) Get(ctx context.Context, key string) (string, error) {
+	time.Sleep(s.delay)
+	return s.MockRedisClient.Get(ctx, key)
+}
+
+func findHandler(t *testing.T, tools []server.ServerTool, name string) server.ToolHandlerFunc {
+	t.Helper()
+	for _, tool := range tools {
+		if tool.Tool.Name == name {
+			return tool.Handler
+		}
+	}
+	t.Fatalf("tool %q not registered", name)
+	return nil
+}
+
+// callCancelled invokes handler with a context that is cancelled shortly
+// after the call starts and waits for the backend to report what it saw.
+func callCancelled(t *testing.T, handler server.ToolHandlerFunc, args map[string]any, observed chan error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := handler(ctx, req)
+		errc <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-observed:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("backend observed %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("backend never observed the request context being cancelled")
+	}
+
+	if err := <-errc; err == nil {
+		t.Error("Expected handler to fail after cancellation")
+	}
+}
+
+func TestMemoryHandlersUseRequestContext(t *testing.T) {
+	index := &blockingVectorIndex{MockVectorIndex: NewMockVectorIndex(), observed: make(chan error, 1)}
+	handler := findHandler(t, memory.NewService(index, memory.DefaultConfig()).Tools(), "search-memory")
+
+	callCancelled(t, handler, map[string]any{"query": "anything"}, index.observed)
+}
+
+func TestPapersHandlersUseRequestContext(t *testing.T) {
+	store := &blockingStore{MockRedisClient: NewMockRedisClient(), observed: make(chan error, 1)}
+	handler := findHandler(t, papers.NewService(store, papers.DefaultConfig()).Tools(), "get-research-paper")
+
+	callCancelled(t, handler, map[string]any{"title": "anything"}, store.observed)
+}
+
+func TestUpstashIndexReturnsOnCancellation(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	index := memory.NewUpstashIndex(vector.NewIndex(backend.URL, "token"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := index.QueryData(ctx, vector.QueryData{Data: "anything", TopK: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("QueryData error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("QueryData took %v after its context expired", elapsed)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	call := func(t *testing.T, store papers.Store) (*mcp.CallToolResult, time.Duration) {
+		t.Helper()
+		handler := timeout.Middleware(20 * time.Millisecond)(findHandler(t, papers.NewService(store, papers.DefaultConfig()).Tools(), "get-research-paper"))
+		var req mcp.CallToolRequest
+		req.Params.Name = "get-research-paper"
+		req.Params.Arguments = map[string]any{"title": "anything"}
+
+		start := time.Now()
+		result, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatal("Expected the timeout reported as a result, got error:", err)
+		}
+		return result, time.Since(start)
+	}
+
+	t.Run("slow store", func(t *testing.T) {
+		result, elapsed := call(t, &slowStore{MockRedisClient: NewMockRedisClient(), delay: 500 * time.Millisecond})
+		if !result.IsError {
+			t.Fatal("Expected an error result")
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "tool 'get-research-paper' timed out after 20ms") {
+			t.Errorf("Got %q, want the timeout explained", text)
+		}
+		if elapsed > 250*time.Millisecond {
+			t.Errorf("Call took %v, want it cut off at the timeout", elapsed)
+		}
+	})
+
+	t.Run("store sees the deadline", func(t *testing.T) {
+		store := &blockingStore{MockRedisClient: NewMockRedisClient(), observed: make(chan error, 1)}
+		if result, _ := call(t, store); !result.IsError {
+			t.Error("Expected an error result")
+		}
+		select {
+		case err := <-store.observed:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Store observed %v, want context.DeadlineExceeded", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Store never observed the deadline")
+		}
+	})
+
+	t.Run("fast store", func(t *testing.T) {
+		if result, _ := call(t, NewMockRedisClient()); result.IsError {
+			t.Errorf("Expected a call within the timeout to succeed, got %+v", result.Content)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/cors"
+)
+
+func TestCORS(t *testing.T) {
+	// The handler streams an event and flushes it, like the SSE endpoint,
+	// behind bearer auth as in the cmd programs.
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: endpoint\ndata: /mcp/message\n\n"))
+		w.(http.Flusher).Flush()
+	})
+	srv := httptest.NewServer(cors.Handler([]string{"https://app.example.com"}, auth.Bearer("s3cret", stream)))
+	defer srv.Close()
+
+	send := func(t *testing.T, method, origin string, header map[string]string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+"/mcp/sse", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "https://app.example.com", map[string]string{"Authorization": "Bearer s3cret"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Got status %d, want 200", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Got Access-Control-Allow-Origin %q", got)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+			t.Errorf("Expected the SSE content type to be kept, got %q", got)
+		}
+		if got := resp.Header.Get("Access-Control-Expose-Headers"); got != "Mcp-Session-Id" {
+			t.Errorf("Expected the session ID header exposed, got %q", got)
+		}
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || line != "event: endpoint\n" {
+			t.Errorf("Expected the streamed event, got %q, %v", line, err)
+		}
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		resp := send(t, http.MethodGet, "https://evil.example.com", map[string]string{"Authorization": "Bearer s3cret"})
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no Access-Control-Allow-Origin, got %q", got)
+		}
+
+		resp = send(t, http.MethodOptions, "https://evil.example.com", map[string]string{"Access-Control-Request-Method": "POST"})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("Expected the preflight to be refused, got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("streamable http preflight", func(t *testing.T) {
+		// A Streamable HTTP client resuming a session sends its ID, the
+		// protocol version and the last event it saw.
+		resp := send(t, http.MethodOptions, "https://app.example.com", map[string]string{
+			"Access-Control-Request-Method":  "DELETE",
+			"Access-Control-Request-Headers": "authorization, mcp-session-id, mcp-protocol-version, last-event-id",
+		})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Got status %d, want 204", resp.StatusCode)
+		}
+		allowed := strings.ToLower(resp.Header.Get("Access-Control-Allow-Headers"))
+		for _, header := range []string{"authorization", "mcp-session-id", "mcp-protocol-version", "last-event-id"} {
+			if !strings.Contains(allowed, header) {
+				t.Errorf("Expected %s to be allowed, got %q", header, allowed)
+			}
+		}
+		if methods := resp.Header.Get("Access-Control-Allow-Methods"); !strings.Contains(methods, "DELETE") {
+			t.Errorf("Expected DELETE to be allowed, got %q", methods)
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		// Browsers send preflights without credentials, so they must be
+		// answered before auth.
+		resp := send(t, http.MethodOptions, "https://app.example.com", map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "authorization, content-type",
+		})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("Got status %d, want 204", resp.StatusCode)
+		}
+		want := map[string]string{
+			"Access-Control-Allow-Origin":  "https://app.example.com",
+			"Access-Control-Allow-Methods": "GET, POST, DELETE, OPTIONS",
+			"Access-Control-Allow-Headers": "Authorization, Content-Type, X-Client-ID, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID",
+		}
+		for key, value := range want {
+			if got := resp.Header.Get(key); got != value {
+				t.Errorf("Got %s %q, want %q", key, got, value)
+			}
+		}
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("Origin", "http://localhost:5173")
+		cors.Handler([]string{"*"}, stream).ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Got Access-Control-Allow-Origin %q, want *", got)
+		}
+	})
+}
+
+func TestCORSParseOrigins(t *testing.T) {
+	got := cors.ParseOrigins(" https://a.example.com, ,https://b.example.com ")
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got := cors.ParseOrigins(""); got != nil {
+		t.Errorf("Expected no origins by default, got %v", got)
+	}
+	if strings.Join(cors.ParseOrigins("*"), "") != "*" {
+		t.Error("Expected the wildcard to be kept")
+	}
+}
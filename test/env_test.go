@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/env"
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+)
+
+func TestPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "unset uses fallback", value: "", want: 9090},
+		{name: "valid port", value: "3000", want: 3000},
+		{name: "lowest port", value: "1", want: 1},
+		{name: "highest port", value: "65535", want: 65535},
+		{name: "zero", value: "0", wantErr: true},
+		{name: "negative", value: "-80", wantErr: true},
+		{name: "too large", value: "65536", wantErr: true},
+		{name: "not a number", value: "http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORT", tt.value)
+
+			got, err := env.Port("PORT", 9090)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got port %d", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("Got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexFromEnvWithoutDotenv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("VECTOR_DB_URL", "https://example-vector.upstash.io")
+	t.Setenv("TOKEN", "secret")
+
+	if err := env.LoadFile(".env"); err != nil {
+		t.Fatalf("Expected a missing .env to be ignored, got: %v", err)
+	}
+
+	index, err := memory.NewIndexFromEnv(memory.NewHTTPClient(1))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if index == nil {
+		t.Fatal("Expected an index")
+	}
+
+	t.Setenv("TOKEN", "")
+	if _, err := memory.NewIndexFromEnv(memory.NewHTTPClient(1)); err == nil {
+		t.Error("Expected an error without TOKEN")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/health"
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/upstash/vector-go"
+)
+
+func newHealthServer(t *testing.T, ping func(ctx context.Context) error) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/", http.NotFoundHandler())
+	health.Register(mux, health.DefaultPaths, "1.2.3", time.Now().Add(-90*time.Second), ping, 50*time.Millisecond)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHealthz(t *testing.T) {
+	srv := newHealthServer(t, func(ctx context.Context) error { return nil })
+
+	resp, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Got status %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Got Content-Type %q, want application/json", ct)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal("Decoding body:", err)
+	}
+	if body["status"] != "ok" || body["version"] != "1.2.3" {
+		t.Errorf("Unexpected status or version: %v", body)
+	}
+	if uptime, _ := body["uptime"].(string); uptime != "1m30s" {
+		t.Errorf("Got uptime %v, want 1m30s", body["uptime"])
+	}
+	if seconds, _ := body["uptime_seconds"].(float64); seconds < 90 {
+		t.Errorf("Got uptime_seconds %v, want at least 90", body["uptime_seconds"])
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name   string
+		ping   func(ctx context.Context) error
+		status int
+	}{
+		{
+			name:   "reachable store",
+			ping:   func(ctx context.Context) error { return nil },
+			status: http.StatusOK,
+		},
+		{
+			name:   "unreachable store",
+			ping:   func(ctx context.Context) error { return errors.New("connection refused") },
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name: "hung store times out",
+			ping: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHealthServer(t, tt.ping)
+
+			resp, err := http.Get(srv.URL + "/readyz")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("Got status %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+// unauthorizedIndex fails every Info call the way Upstash answers a wrong
+// token.
+type unauthorizedIndex struct {
+	*MockVectorIndex
+}
+
+func (unauthorizedIndex) Info(ctx context.Context) (vector.IndexInfo, error) {
+	return vector.IndexInfo{}, errors.New("Unauthorized: Invalid auth token")
+}
+
+func TestCheckIndex(t *testing.T) {
+	ctx := context.Background()
+
+	if err := memory.CheckIndex(ctx, NewMockVectorIndex()); err != nil {
+		t.Errorf("Unexpected error for a healthy index: %v", err)
+	}
+
+	err := memory.CheckIndex(ctx, unauthorizedIndex{NewMockVectorIndex()})
+	if err == nil || !strings.Contains(err.Error(), "rejected the credentials, check TOKEN") {
+		t.Errorf("Expected a credentials error, got %v", err)
+	}
+
+	// The real client against a server refusing the token, as Upstash does.
+	upstash := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer right" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Unauthorized: Invalid auth token","status":401}`))
+			return
+		}
+		w.Write([]byte(`{"result":{"vectorCount":0,"dimension":64}}`))
+	}))
+	defer upstash.Close()
+
+	connect := func(token string) memory.Index {
+		return memory.NewUpstashIndex(vector.NewIndexWith(vector.Options{
+			Url:    upstash.URL,
+			Token:  token,
+			Client: memory.NewHTTPClient(2),
+		}))
+	}
+	if err := memory.CheckIndex(ctx, connect("right")); err != nil {
+		t.Errorf("Unexpected error with the right token: %v", err)
+	}
+	err = memory.CheckIndex(ctx, connect("wrong"))
+	if err == nil || !strings.Contains(err.Error(), "rejected the credentials, check TOKEN") {
+		t.Errorf("Expected a credentials error, got %v", err)
+	}
+
+	// The readiness probe reports the same check.
+	srv := newHealthServer(t, func(ctx context.Context) error { return memory.CheckIndex(ctx, connect("wrong")) })
+	resp, err := http.Get(srv.URL + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body health.Readiness
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal("Decoding body:", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || !strings.Contains(body.Error, "check TOKEN") {
+		t.Errorf("Got status %d and %+v, want 503 naming TOKEN", resp.StatusCode, body)
+	}
+
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	err = memory.CheckIndex(ctx, memory.NewUpstashIndex(vector.NewIndexWith(vector.Options{Url: dead.URL, Token: "right"})))
+	if err == nil || !strings.Contains(err.Error(), "unreachable, check VECTOR_DB_URL") {
+		t.Errorf("Expected an unreachable error, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	logger := logging.New(&out, slog.LevelInfo)
+
+	var seenID string
+	handler := logging.Middleware(logger)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seenID = logging.RequestID(ctx)
+		if request.GetArguments()["fail"] == true {
+			return nil, errors.New("boom")
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	call := func(args map[string]any) (map[string]any, error) {
+		t.Helper()
+		out.Reset()
+		var request mcp.CallToolRequest
+		request.Params.Name = "search-memory"
+		request.Params.Arguments = args
+		_, err := handler(context.Background(), request)
+
+		var line map[string]any
+		if jsonErr := json.Unmarshal(out.Bytes(), &line); jsonErr != nil {
+			t.Fatalf("log output is not one JSON line: %v: %q", jsonErr, out.String())
+		}
+		return line, err
+	}
+
+	line, err := call(nil)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if line["msg"] != "tool call" || line["level"] != "INFO" || line["tool"] != "search-memory" || line["status"] != "ok" {
+		t.Errorf("Unexpected log line: %v", line)
+	}
+	if _, ok := line["duration"].(float64); !ok {
+		t.Errorf("Expected a duration, got: %v", line)
+	}
+	if id, _ := line["request_id"].(string); len(id) != 16 || id != seenID {
+		t.Errorf("Expected the logged request ID %q to be the one in the handler's context %q", id, seenID)
+	}
+	firstID := seenID
+
+	line, err = call(map[string]any{"fail": true})
+	if err == nil || !strings.Contains(err.Error(), "boom (request_id: "+seenID+")") {
+		t.Errorf("Expected the error to carry the request ID, got: %v", err)
+	}
+	if line["level"] != "ERROR" || line["status"] != "error" || line["error"] != "boom" {
+		t.Errorf("Unexpected log line: %v", line)
+	}
+	if seenID == firstID {
+		t.Error("Expected every call to get its own request ID")
+	}
+
+	if logging.RequestID(context.Background()) != "" {
+		t.Error("Expected no request ID outside a tool call")
+	}
+}
+
+func TestLoggingParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{"": slog.LevelInfo, "debug": slog.LevelDebug, "WARN": slog.LevelWarn, "error": slog.LevelError}
+	for raw, want := range tests {
+		got, err := logging.ParseLevel(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	if _, err := logging.ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for an unknown level")
+	}
+}
@@ -2,202 +2,306 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
 	"github.com/upstash/vector-go"
 )
 
+type mockRecord struct {
+	data     string
+	metadata map[string]any
+	vector   []float32
+}
+
 type MockVectorIndex struct {
-	data map[string]string
+	data   map[string]mockRecord
+	scores map[string]float32
+	// upserts counts the records written, one per record in a batch.
+	upserts int
+	// resets counts the calls to Reset.
+	resets int
+	// namespaces holds the named namespaces; the index itself is the
+	// default one.
+	namespaces map[string]*MockVectorIndex
 }
 
 func NewMockVectorIndex() *MockVectorIndex {
 	return &MockVectorIndex{
-		data: make(map[string]string),
+		data:       make(map[string]mockRecord),
+		scores:     make(map[string]float32),
+		namespaces: make(map[string]*MockVectorIndex),
 	}
 }
 
-func (m *MockVectorIndex) UpsertData(data vector.UpsertData) error {
-	m.data[data.Id] = data.Data
-	return nil
+func (m *MockVectorIndex) Namespace(ns string) memory.Index {
+	if ns == "" {
+		return m
+	}
+	if _, exists := m.namespaces[ns]; !exists {
+		m.namespaces[ns] = NewMockVectorIndex()
+	}
+	return m.namespaces[ns]
 }
 
-type MockScore struct {
-	Id    string
-	Score float64
-	Data  string
+// SetScore fixes the similarity the mock reports for id in searches.
+func (m *MockVectorIndex) SetScore(id string, score float32) {
+	m.scores[id] = score
 }
 
-func (m *MockVectorIndex) QueryData(query vector.QueryData) ([]MockScore, error) {
-	var results []MockScore
-	
-	if query.TopK == 1 {
-		if content, exists := m.data[query.Data]; exists {
-			results = append(results, MockScore{
-				Id:    query.Data,
-				Score: 1.0,
-				Data:  content,
-			})
-		}
-	} else {
-		for id, content := range m.data {
-			if strings.Contains(strings.ToLower(content), strings.ToLower(query.Data)) {
-				results = append(results, MockScore{
-					Id:    id,
-					Score: 0.95,
-					Data:  content,
-				})
-			}
-		}
-		
-		if len(results) > query.TopK {
-			results = results[:query.TopK]
-		}
+// mockEmbed produces a deterministic bag-of-words embedding so identical
+// content yields identical vectors and unrelated content nearly orthogonal ones.
+func mockEmbed(text string) []float32 {
+	vec := make([]float32, 64)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%uint32(len(vec))]++
 	}
-	
-	return results, nil
+	return vec
 }
 
-func createMemoryMCPServer(t *testing.T) *mcptest.Server {
-	srv := mcptest.NewUnstartedServer(t)
-	
-	mockIndex := NewMockVectorIndex()
-	
-	addToMemory := mcp.NewTool("add-to-memory",
-		mcp.WithDescription("Add a new memory or update an existing memory"),
-		mcp.WithString("id",
-			mcp.Required(),
-			mcp.Description("Unique identifier for the memory"),
-		),
-		mcp.WithString("content",
-			mcp.Required(),
-			mcp.Description("The memory content to store"),
-		),
-		mcp.WithString("metadata",
-			mcp.Description("Additional metadata for the memory"),
-		),
-	)
-
-	searchMemory := mcp.NewTool("search-memory",
-		mcp.WithDescription("Search for memories using semantic similarity"),
-		mcp.WithString("query",
-			mcp.Required(),
-			mcp.Description("Search query text"),
-		),
-		mcp.WithNumber("top_k",
-			mcp.Description("Number of results to return (default: 5)"),
-		),
-	)
-
-	getMemory := mcp.NewTool("get-memory",
-		mcp.WithDescription("Get a specific memory by ID"),
-		mcp.WithString("id",
-			mcp.Required(),
-			mcp.Description("Memory ID to retrieve"),
-		),
-	)
-
-	srv.AddTool(addToMemory, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
-
-		id, ok := args["id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("argument 'id' is missing or not a string")
-		}
+func (m *MockVectorIndex) UpsertData(ctx context.Context, data vector.UpsertData) error {
+	m.upserts++
+	m.data[data.Id] = mockRecord{
+		data:     data.Data,
+		metadata: data.Metadata,
+		vector:   mockEmbed(data.Data),
+	}
+	return nil
+}
 
-		content, ok := args["content"].(string)
-		if !ok {
-			return nil, fmt.Errorf("argument 'content' is missing or not a string")
-		}
+func (m *MockVectorIndex) UpsertDataMany(ctx context.Context, data []vector.UpsertData) error {
+	for _, d := range data {
+		m.UpsertData(ctx, d)
+	}
+	return nil
+}
 
-		metadata, _ := args["metadata"].(string)
+func (m *MockVectorIndex) Update(ctx context.Context, u vector.Update) (bool, error) {
+	record, exists := m.data[u.Id]
+	if !exists {
+		return false, nil
+	}
+	m.upserts++
+	if u.Data != "" {
+		record.data = u.Data
+		record.vector = mockEmbed(u.Data)
+	}
+	if u.Metadata != nil {
+		record.metadata = u.Metadata
+	}
+	m.data[u.Id] = record
+	return true, nil
+}
 
-		data := content
-		if metadata != "" {
-			data = fmt.Sprintf("%s [metadata: %s]", content, metadata)
+func (m *MockVectorIndex) Delete(ctx context.Context, id string) (bool, error) {
+	_, exists := m.data[id]
+	delete(m.data, id)
+	return exists, nil
+}
+
+func (m *MockVectorIndex) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		if _, exists := m.data[id]; exists {
+			delete(m.data, id)
+			deleted++
 		}
+	}
+	return deleted, nil
+}
 
-		err := mockIndex.UpsertData(vector.UpsertData{
-			Id:   id,
-			Data: data,
-		})
+func (m *MockVectorIndex) QueryData(ctx context.Context, query vector.QueryData) ([]vector.VectorScore, error) {
+	var results []vector.VectorScore
 
+	for id, record := range m.data {
+		filter, _ := query.Filter.(string)
+		matches, err := mockFilterMatch(filter, record.metadata)
 		if err != nil {
-			return nil, fmt.Errorf("error storing memory: %v", err)
+			return nil, err
+		}
+		if matches && strings.Contains(strings.ToLower(record.data), strings.ToLower(query.Data)) {
+			score, ok := m.scores[id]
+			if !ok {
+				score = 0.95
+			}
+			results = append(results, m.score(id, record, score, query.IncludeVectors))
 		}
+	}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Successfully stored memory with ID: %s", id)), nil
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Id < results[j].Id
 	})
 
-	srv.AddTool(searchMemory, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
+	if len(results) > query.TopK {
+		results = results[:query.TopK]
+	}
 
-		query, ok := args["query"].(string)
-		if !ok {
-			return nil, fmt.Errorf("argument 'query' is missing or not a string")
+	return results, nil
+}
+
+var mockFilterClause = regexp.MustCompile(`^(\w+) (=|CONTAINS) '([^']*)'$`)
+
+// mockFilterMatch evaluates the subset of the Upstash filter syntax the
+// tests use: "field = 'value'" and "field CONTAINS 'value'" clauses joined
+// by AND.
+func mockFilterMatch(filter string, metadata map[string]any) (bool, error) {
+	if filter == "" {
+		return true, nil
+	}
+	for _, clause := range strings.Split(filter, " AND ") {
+		parts := mockFilterClause.FindStringSubmatch(clause)
+		if parts == nil {
+			return false, fmt.Errorf("mock index cannot evaluate filter clause %q", clause)
 		}
+		field, op, want := parts[1], parts[2], parts[3]
 
-		topK := 5
-		if topKArg, exists := args["top_k"]; exists {
-			if topKFloat, ok := topKArg.(float64); ok {
-				topK = int(topKFloat)
-			} else if topKStr, ok := topKArg.(string); ok {
-				if parsed, err := strconv.Atoi(topKStr); err == nil {
-					topK = parsed
-				}
+		switch value := metadata[field].(type) {
+		case string:
+			if op != "=" || value != want {
+				return false, nil
+			}
+		case []any:
+			if op != "CONTAINS" || !slices.Contains(value, any(want)) {
+				return false, nil
 			}
+		default:
+			return false, nil
 		}
+	}
+	return true, nil
+}
 
-		scores, err := mockIndex.QueryData(vector.QueryData{
-			Data: query,
-			TopK: topK,
-		})
+func (m *MockVectorIndex) score(id string, record mockRecord, score float32, includeVectors bool) vector.VectorScore {
+	result := vector.VectorScore{
+		Id:       id,
+		Score:    score,
+		Data:     record.data,
+		Metadata: record.metadata,
+	}
+	if includeVectors {
+		result.Vector = record.vector
+	}
+	return result
+}
 
-		if err != nil {
-			return nil, fmt.Errorf("error searching memories: %v", err)
+// Fetch mirrors Upstash by returning one entry per requested ID, with a zero
+// Vector in place of IDs that do not exist.
+func (m *MockVectorIndex) Fetch(ctx context.Context, f vector.Fetch) ([]vector.Vector, error) {
+	vectors := make([]vector.Vector, len(f.Ids))
+	for i, id := range f.Ids {
+		record, exists := m.data[id]
+		if !exists {
+			continue
 		}
-
-		if len(scores) == 0 {
-			return mcp.NewToolResultText("No memories found matching your query"), nil
+		vectors[i] = vector.Vector{
+			Id:       id,
+			Data:     record.data,
+			Metadata: record.metadata,
 		}
-
-		result := fmt.Sprintf("Found %d memories:\n", len(scores))
-		for i, score := range scores {
-			result += fmt.Sprintf("%d. ID: %s, Score: %.4f, Content: %s\n", i+1, score.Id, score.Score, score.Data)
+		if f.IncludeVectors {
+			vectors[i].Vector = record.vector
 		}
+	}
+	return vectors, nil
+}
 
-		return mcp.NewToolResultText(result), nil
-	})
+// Range pages through the records in ID order, using the offset of the next
+// record as the cursor and an empty cursor once the last page is returned.
+func (m *MockVectorIndex) Range(ctx context.Context, r vector.Range) (vector.RangeVectors, error) {
+	ids := make([]string, 0, len(m.data))
+	for id := range m.data {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
 
-	srv.AddTool(getMemory, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
+	start := 0
+	if r.Cursor != "" {
+		var err error
+		if start, err = strconv.Atoi(r.Cursor); err != nil {
+			return vector.RangeVectors{}, fmt.Errorf("invalid cursor %q", r.Cursor)
+		}
+	}
+	end := min(start+r.Limit, len(ids))
 
-		id, ok := args["id"].(string)
-		if !ok {
-			return nil, fmt.Errorf("argument 'id' is missing or not a string")
+	var page vector.RangeVectors
+	for _, id := range ids[start:end] {
+		record := m.data[id]
+		v := vector.Vector{Id: id, Data: record.data, Metadata: record.metadata}
+		if r.IncludeVectors {
+			v.Vector = record.vector
 		}
+		page.Vectors = append(page.Vectors, v)
+	}
+	if end < len(ids) {
+		page.NextCursor = strconv.Itoa(end)
+	}
+	return page, nil
+}
 
-		scores, err := mockIndex.QueryData(vector.QueryData{
-			Data: id,
-			TopK: 1,
-		})
+func (m *MockVectorIndex) Reset(ctx context.Context) error {
+	m.resets++
+	m.data = make(map[string]mockRecord)
+	return nil
+}
 
-		if err != nil {
-			return nil, fmt.Errorf("error retrieving memory: %v", err)
-		}
+// Info reports like Upstash: VectorCount covers every namespace, and
+// Namespaces breaks it down with "" for the default one.
+func (m *MockVectorIndex) Info(ctx context.Context) (vector.IndexInfo, error) {
+	info := vector.IndexInfo{
+		VectorCount: len(m.data),
+		Dimension:   64,
+		Namespaces:  map[string]vector.NamespaceInfo{"": {VectorCount: len(m.data)}},
+	}
+	for ns, child := range m.namespaces {
+		info.VectorCount += len(child.data)
+		info.Namespaces[ns] = vector.NamespaceInfo{VectorCount: len(child.data)}
+	}
+	return info, nil
+}
 
-		if len(scores) == 0 || scores[0].Id != id {
-			return mcp.NewToolResultText(fmt.Sprintf("Memory with ID '%s' not found", id)), nil
-		}
+func createMemoryMCPServer(t *testing.T) *mcptest.Server {
+	return createMemoryMCPServerWith(t, NewMockVectorIndex(), memory.DefaultConfig())
+}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Memory ID: %s\nContent: %s", scores[0].Id, scores[0].Data)), nil
-	})
+func createMemoryMCPServerWith(t *testing.T, index memory.Index, cfg memory.Config) *mcptest.Server {
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddTools(memory.NewService(index, cfg).Tools()...)
+	return srv
+}
 
+// createAdminMemoryMCPServerWith is createMemoryMCPServerWith with every
+// call made as an admin, for the tools that need one.
+func createAdminMemoryMCPServerWith(t *testing.T, index memory.Index, cfg memory.Config) *mcptest.Server {
+	srv := mcptest.NewUnstartedServer(t)
+	for _, tool := range memory.NewService(index, cfg).Tools() {
+		srv.AddTools(withGrant(auth.Grant{Admin: true}, tool))
+	}
 	return srv
 }
 
@@ -231,7 +335,7 @@ func TestAddToMemory(t *testing.T) {
 			args: map[string]any{
 				"id":       "test-2",
 				"content":  "This is another test memory",
-				"metadata": "important, urgent",
+				"metadata": map[string]any{"tags": []any{"important", "urgent"}},
 			},
 			expected: "Successfully stored memory with ID: test-2",
 		},
@@ -260,6 +364,76 @@ func TestAddToMemory(t *testing.T) {
 	}
 }
 
+func TestAddToMemoryStructuredMetadata(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	nested := map[string]any{
+		"project": map[string]any{"name": "apollo", "phase": float64(2)},
+		"tags":    []any{"launch", "review"},
+	}
+	for id, metadata := range map[string]any{
+		"object": nested,
+		"string": `{"project": {"name": "apollo", "phase": 2}, "tags": ["launch", "review"]}`,
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "launch checklist " + id, "metadata": metadata}); err != nil {
+			t.Fatalf("CallTool with %s metadata: %v", id, err)
+		}
+
+		record := index.data[id]
+		if record.data != "launch checklist "+id {
+			t.Errorf("Expected metadata kept out of the embedded text, got %q", record.data)
+		}
+		project, _ := record.metadata["project"].(map[string]any)
+		if project["name"] != "apollo" || project["phase"] != float64(2) {
+			t.Errorf("Expected nested metadata stored, got %v", record.metadata)
+		}
+	}
+
+	const shown = `{"project":{"name":"apollo","phase":2},"tags":["launch","review"]}`
+
+	got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "object"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	got = withoutTimestamps(got)
+	if expected := "Memory ID: object\nContent: launch checklist object\nMetadata: " + shown; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "launch checklist"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Content: launch checklist string\n   Metadata: "+shown+"\n") {
+		t.Errorf("Expected metadata listed separately from content, got: %s", got)
+	}
+
+	for name, metadata := range map[string]any{
+		"invalid JSON":   "important, urgent",
+		"JSON array":     `["a", "b"]`,
+		"JSON null":      "null",
+		"reserved key":   map[string]any{"content_hash": "forged"},
+		"wrong arg type": 42,
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "bad", "content": "x", "metadata": metadata}); err == nil {
+			t.Errorf("%s: expected error but got none", name)
+		}
+	}
+	if _, exists := index.data["bad"]; exists {
+		t.Error("Invalid metadata was stored")
+	}
+}
+
 func TestAddToMemoryErrors(t *testing.T) {
 	ctx := context.Background()
 	srv := createMemoryMCPServer(t)
@@ -318,6 +492,158 @@ func TestAddToMemoryErrors(t *testing.T) {
 	}
 }
 
+func TestAddToMemoryMinContentLength(t *testing.T) {
+	ctx := context.Background()
+	cfg := memory.DefaultConfig()
+	cfg.MinContentLength = 5
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "below minimum", content: "abcd", wantErr: true},
+		{name: "at minimum", content: "abcde", wantErr: false},
+		{name: "above minimum", content: "abcdef", wantErr: false},
+		{name: "multi-byte runes at minimum", content: "héllö", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req mcp.CallToolRequest
+			req.Params.Name = "add-to-memory"
+			req.Params.Arguments = map[string]any{
+				"id":      "test-min",
+				"content": tt.content,
+			}
+
+			_, err := client.CallTool(ctx, req)
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAddToMemorySanitizeInput(t *testing.T) {
+	ctx := context.Background()
+	raw := "line\x00 one\x1b[31m red\x7f\nline\ttwo, caf\u00e9 \u65e5\u672c\u0085"
+
+	tests := []struct {
+		name     string
+		sanitize bool
+		want     string
+	}{
+		{name: "sanitized by default", sanitize: true, want: "line one[31m red\nline\ttwo, caf\u00e9 \u65e5\u672c"},
+		{name: "kept when disabled", sanitize: false, want: raw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewMockVectorIndex()
+			cfg := memory.DefaultConfig()
+			cfg.SanitizeInput = tt.sanitize
+			srv := createMemoryMCPServerWith(t, index, cfg)
+			defer srv.Close()
+
+			err := srv.Start(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := srv.Client()
+
+			if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "note", "content": raw}); err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got := index.data["note"].data; got != tt.want {
+				t.Errorf("Stored %q, want %q", got, tt.want)
+			}
+
+			got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "note"})
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("Expected %q on retrieval, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Setenv("SANITIZE_INPUT", "false")
+	cfg, err := memory.ConfigFromEnv()
+	if err != nil {
+		t.Fatal("ConfigFromEnv:", err)
+	}
+	if cfg.SanitizeInput {
+		t.Error("Expected SANITIZE_INPUT=false to disable sanitization")
+	}
+}
+
+func TestMaxContentBytes(t *testing.T) {
+	ctx := context.Background()
+	limit := memory.DefaultConfig().MaxContentBytes
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "big", "content": "seed"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "just under the limit", content: strings.Repeat("a", limit-1), wantErr: false},
+		{name: "at the limit", content: strings.Repeat("a", limit), wantErr: false},
+		{name: "just over the limit", content: strings.Repeat("a", limit+1), wantErr: true},
+		// Half as many runes as the limit, but each takes two bytes.
+		{name: "multi-byte runes over the limit", content: strings.Repeat("é", limit/2+1), wantErr: true},
+	}
+
+	for _, tool := range []string{"add-to-memory", "update-memory"} {
+		for _, tt := range tests {
+			t.Run(tool+" "+tt.name, func(t *testing.T) {
+				_, err := callTool(ctx, client, tool, map[string]any{
+					"id":      "big",
+					"content": tt.content,
+				})
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("Expected error but got none")
+					}
+					if want := fmt.Sprintf("content must be at most %d bytes long", limit); !strings.Contains(err.Error(), want) {
+						t.Errorf("Got error %q, want it to contain %q", err, want)
+					}
+				}
+				if !tt.wantErr && err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			})
+		}
+	}
+}
+
 func TestSearchMemory(t *testing.T) {
 	ctx := context.Background()
 	srv := createMemoryMCPServer(t)
@@ -400,9 +726,10 @@ func TestSearchMemoryNoResults(t *testing.T) {
 	}
 }
 
-func TestGetMemory(t *testing.T) {
+func TestMemoryNamespaces(t *testing.T) {
 	ctx := context.Background()
-	srv := createMemoryMCPServer(t)
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
 	defer srv.Close()
 
 	err := srv.Start(ctx)
@@ -412,42 +739,65 @@ func TestGetMemory(t *testing.T) {
 
 	client := srv.Client()
 
-	var addReq mcp.CallToolRequest
-	addReq.Params.Name = "add-to-memory"
-	addReq.Params.Arguments = map[string]any{
-		"id":      "test-memory",
-		"content": "This is a specific test memory",
-	}
-	_, err = client.CallTool(ctx, addReq)
-	if err != nil {
-		t.Fatal("Setup failed:", err)
+	for _, args := range []map[string]any{
+		{"id": "pet", "content": "alice has a cat", "namespace": "alice"},
+		{"id": "pet", "content": "bob has a dog", "namespace": "bob"},
+		{"id": "pet", "content": "nobody has a fish"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("CallTool:", err)
+		}
 	}
 
-	var getReq mcp.CallToolRequest
-	getReq.Params.Name = "get-memory"
-	getReq.Params.Arguments = map[string]any{
-		"id": "test-memory",
+	for ns, content := range map[string]string{
+		"alice": "alice has a cat",
+		"bob":   "bob has a dog",
+		"":      "nobody has a fish",
+	} {
+		args := map[string]any{"id": "pet"}
+		if ns != "" {
+			args["namespace"] = ns
+		}
+		got, err := callTool(ctx, client, "get-memory", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		got = withoutTimestamps(got)
+		if expected := "Memory ID: pet\nContent: " + content; got != expected {
+			t.Errorf("get-memory in namespace %q: got %q, want %q", ns, got, expected)
+		}
+
+		args = map[string]any{"query": "has"}
+		if ns != "" {
+			args["namespace"] = ns
+		}
+		got, err = callTool(ctx, client, "search-memory", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		if !strings.Contains(got, "Found 1 memories") || !strings.Contains(got, content) {
+			t.Errorf("search-memory in namespace %q: expected only %q, got: %s", ns, content, got)
+		}
 	}
 
-	result, err := client.CallTool(ctx, getReq)
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "cat", "namespace": "bob"})
 	if err != nil {
 		t.Fatal("CallTool:", err)
 	}
-
-	got, err := resultToString(result)
-	if err != nil {
-		t.Fatal(err)
+	if got != "No memories found matching your query" {
+		t.Errorf("Expected no results across namespaces, got: %s", got)
 	}
 
-	if !strings.Contains(got, "Memory ID: test-memory") {
-		t.Errorf("Expected memory ID in response, got: %s", got)
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"top_k": 3})
+	if err != nil {
+		t.Fatal("CallTool:", err)
 	}
-	if !strings.Contains(got, "This is a specific test memory") {
-		t.Errorf("Expected memory content in response, got: %s", got)
+	if strings.Contains(got, "alice has a cat") {
+		t.Errorf("Expected refine-search to stay in the namespace, got: %s", got)
 	}
 }
 
-func TestGetMemoryNotFound(t *testing.T) {
+func TestSearchMemoryFilter(t *testing.T) {
 	ctx := context.Background()
 	srv := createMemoryMCPServer(t)
 	defer srv.Close()
@@ -459,26 +809,3327 @@ func TestGetMemoryNotFound(t *testing.T) {
 
 	client := srv.Client()
 
-	var getReq mcp.CallToolRequest
-	getReq.Params.Name = "get-memory"
-	getReq.Params.Arguments = map[string]any{
-		"id": "nonexistent",
+	seed := []map[string]any{
+		{"id": "alpha-1", "content": "launch notes for alpha", "metadata": map[string]any{"tags": []any{"project:alpha", "urgent"}}},
+		{"id": "alpha-2", "content": "launch retro for alpha", "metadata": map[string]any{"tags": []any{"project:alpha"}}},
+		{"id": "beta-1", "content": "launch notes for beta", "metadata": map[string]any{"tags": []any{"project:beta"}}},
 	}
-
-	result, err := client.CallTool(ctx, getReq)
-	if err != nil {
-		t.Fatal("CallTool:", err)
+	for _, args := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
 	}
 
-	got, err := resultToString(result)
-	if err != nil {
-		t.Fatal(err)
+	tests := []struct {
+		name   string
+		filter string
+		ids    []string
+	}{
+		{name: "no filter", filter: "", ids: []string{"alpha-1", "alpha-2", "beta-1"}},
+		{name: "one tag", filter: "tags CONTAINS 'project:alpha'", ids: []string{"alpha-1", "alpha-2"}},
+		{name: "two tags", filter: "tags CONTAINS 'project:alpha' AND tags CONTAINS 'urgent'", ids: []string{"alpha-1"}},
 	}
 
-	expected := "Memory with ID 'nonexistent' not found"
-	if got != expected {
-		t.Errorf("Got %q, want %q", got, expected)
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "launch", "filter": tt.filter})
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if prefix := fmt.Sprintf("Found %d memories:", len(tt.ids)); !strings.HasPrefix(got, prefix) {
+				t.Errorf("Expected %q, got: %s", prefix, got)
+			}
+			assertIDOrder(t, got, tt.ids)
+		})
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "launch", "filter": "tags CONTAINS 'project:beta'", "explain": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "metadata matches filter tags CONTAINS 'project:beta'") {
+		t.Errorf("Expected explanation to name the filter, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"filter": ""})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 3 memories:") {
+		t.Errorf("Expected refine-search to clear the filter, got: %s", got)
+	}
+
+	if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "launch", "filter": "tags CONTAINS"}); err == nil {
+		t.Error("Expected error for malformed filter but got none")
+	}
+}
+
+func TestSearchMemoryTags(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := []map[string]any{
+		{"id": "both", "content": "launch plan", "metadata": map[string]any{"tags": []any{"work", "urgent"}}},
+		{"id": "work", "content": "launch review", "metadata": map[string]any{"tags": []any{"work"}}},
+		{"id": "urgent", "content": "launch party", "metadata": map[string]any{"tags": []any{"urgent", "fun"}}},
+		{"id": "untagged", "content": "launch snacks"},
+	}
+	for _, args := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		ids  []string
+	}{
+		{name: "all tags", args: map[string]any{"tags": []any{"work", "urgent"}}, ids: []string{"both"}},
+		{name: "any tag", args: map[string]any{"tags": []any{"work", "urgent"}, "tags_any": true}, ids: []string{"both", "urgent", "work"}},
+		{name: "single tag", args: map[string]any{"tags": []any{"fun"}}, ids: []string{"urgent"}},
+		{name: "no tags", args: map[string]any{"tags": []any{}}, ids: []string{"both", "untagged", "urgent", "work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"query": "launch"}
+			maps.Copy(args, tt.args)
+			got, err := callTool(ctx, client, "search-memory", args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if prefix := fmt.Sprintf("Found %d memories:", len(tt.ids)); !strings.HasPrefix(got, prefix) {
+				t.Errorf("Expected %q, got: %s", prefix, got)
+			}
+			assertIDOrder(t, got, tt.ids)
+		})
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "launch", "tags": []any{"work"}, "explain": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "tagged with all of work") {
+		t.Errorf("Expected explanation to name the tags, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"tags_any": true, "tags": []any{"fun", "work"}})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 3 memories:") {
+		t.Errorf("Expected refine-search to switch to any of the tags, got: %s", got)
+	}
+
+	for _, tags := range []any{"work", []any{"work", 3}, []any{""}} {
+		if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "launch", "tags": tags}); err == nil {
+			t.Errorf("tags %v: expected error but got none", tags)
+		}
+	}
+}
+
+func TestRefineSearch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := memory.DefaultConfig()
+	cfg.SearchSessionTTL = time.Minute
+	cfg.Now = func() time.Time { return now }
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "refine-search", map[string]any{"top_k": 1}); err == nil {
+		t.Error("Expected error refining without a previous search")
+	}
+
+	for _, id := range []string{"go-1", "go-2", "go-3"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "notes about programming in Go"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "programming"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 3 memories:") {
+		t.Fatalf("Expected 3 memories from initial search, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"top_k": 2})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Refined search for 'programming' (top_k: 2)") {
+		t.Errorf("Expected refine to reuse the previous query, got: %s", got)
+	}
+	if !strings.Contains(got, "Found 2 memories:") {
+		t.Errorf("Expected refine to apply top_k 2, got: %s", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := callTool(ctx, client, "refine-search", map[string]any{"top_k": 1}); err == nil {
+		t.Error("Expected error refining after the session expired")
+	}
+}
+
+func TestSearchMemoryOrder(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, score := range map[string]float32{"close": 0.9, "middle": 0.7, "far": 0.5} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "a note about gardening"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		index.SetScore(id, score)
+	}
+
+	tests := []struct {
+		name  string
+		args  map[string]any
+		order []string
+	}{
+		{name: "default is descending", args: map[string]any{"query": "gardening"}, order: []string{"close", "middle", "far"}},
+		{name: "explicit descending", args: map[string]any{"query": "gardening", "order": "desc"}, order: []string{"close", "middle", "far"}},
+		{name: "ascending", args: map[string]any{"query": "gardening", "order": "asc"}, order: []string{"far", "middle", "close"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "search-memory", tt.args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			assertIDOrder(t, got, tt.order)
+		})
+	}
+
+	if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "gardening", "order": "sideways"}); err == nil {
+		t.Error("Expected error for invalid order")
+	}
+}
+
+func TestSearchMemoryMinScore(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, score := range map[string]float32{"strong": 0.95, "weak": 0.60} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "a note about hiking"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		index.SetScore(id, score)
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "hiking", "min_score": 0.8})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 1 memories") || !strings.Contains(got, "ID: strong") || strings.Contains(got, "ID: weak") {
+		t.Errorf("Expected only the strong match, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "hiking", "min_score": 0.6})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 2 memories") {
+		t.Errorf("Expected a score equal to the cutoff to be kept, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"min_score": 0.99})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasSuffix(got, "No memories found matching your query") {
+		t.Errorf("Expected everything filtered out, got: %s", got)
+	}
+
+	for _, minScore := range []any{-0.1, 1.5, "high"} {
+		if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "hiking", "min_score": minScore}); err == nil {
+			t.Errorf("min_score %v: expected error but got none", minScore)
+		}
+	}
+}
+func TestSearchMemoryRecencyBoost(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	memories := []struct {
+		id        string
+		createdAt string
+		score     float32
+	}{
+		{id: "older", createdAt: "2020-01-01T00:00:00Z", score: 0.9},
+		{id: "newer", createdAt: "2025-01-01T00:00:00Z", score: 0.6},
+	}
+	for _, m := range memories {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": m.id, "content": "a note about hiking", "created_at": m.createdAt}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		index.SetScore(m.id, m.score)
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "hiking"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Index(got, "ID: older") > strings.Index(got, "ID: newer") {
+		t.Errorf("Expected pure similarity to rank the older memory first, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "hiking", "recency_boost": 0.8})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Index(got, "ID: newer") > strings.Index(got, "ID: older") {
+		t.Errorf("Expected a high boost to rank the newer memory first, got: %s", got)
+	}
+	if !strings.Contains(got, "ID: newer, Score: 0.6000, Boosted: 0.9200") {
+		t.Errorf("Expected the boosted score to be shown, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"recency_boost": 0})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Index(got, "ID: older") > strings.Index(got, "ID: newer") || strings.Contains(got, "Boosted") {
+		t.Errorf("Expected a zero boost to restore pure similarity, got: %s", got)
+	}
+
+	for _, boost := range []any{-0.1, 1.5, "high"} {
+		if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "hiking", "recency_boost": boost}); err == nil {
+			t.Errorf("recency_boost %v: expected error but got none", boost)
+		}
+	}
+}
+
+func TestSearchMemoryPreviewChars(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	// Multi-byte runes throughout, so a byte-based cut would split one.
+	content := strings.Repeat("日本語のメモ🚀 ", 40)
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "long", "content": content}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "メモ", "preview_chars": 10})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	want := "1. ID: long, Score: 0.9500, Content: " + string([]rune(content)[:10]) + "...\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected a 10-character preview, got: %q", got)
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("Preview split a rune: %q", got)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"format": "json", "preview_chars": 7})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	var results []memory.MemoryResult
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || utf8.RuneCountInString(results[0].Content) != 7+len("...") || !utf8.ValidString(results[0].Content) {
+		t.Errorf("Expected a 7-character JSON preview, got: %+v", results)
+	}
+
+	got, err = callTool(ctx, client, "refine-search", map[string]any{"format": "text", "preview_chars": 0})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, content) {
+		t.Errorf("Expected preview_chars 0 to show the full content, got: %q", got)
+	}
+
+	for _, chars := range []any{-1, 2.5, "ten"} {
+		if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "メモ", "preview_chars": chars}); err == nil {
+			t.Errorf("preview_chars %v: expected error but got none", chars)
+		}
+	}
+}
+
+func TestSearchMemoryTemplate(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, m := range []map[string]any{
+		{"id": "tea", "content": "I drink green tea", "metadata": map[string]any{"mood": "calm"}},
+		{"id": "coffee", "content": "I drink coffee"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", m); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	index.scores["tea"] = 0.9
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{
+		"query":    "drink",
+		"template": `[{{.id}}] {{printf "%.2f" .score}} {{.content}}{{with .metadata.mood}} ({{.}}){{end}}`,
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	expected := "Found 2 memories:\n[coffee] 0.95 I drink coffee\n[tea] 0.90 I drink green tea (calm)\n"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "drink", "template": ""})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "1. ID: coffee, Score: 0.9500, Content: I drink coffee") {
+		t.Errorf("Expected the default format without a template, got: %q", got)
+	}
+
+	_, err = callTool(ctx, client, "search-memory", map[string]any{"query": "drink", "template": "{{.id"})
+	if err == nil || !strings.Contains(err.Error(), "not a valid template") {
+		t.Errorf("Expected a template parse error, got: %v", err)
+	}
+}
+
+func TestSearchMemoryTopKRounding(t *testing.T) {
+	ctx := context.Background()
+
+	strict := memory.DefaultConfig()
+	strict.StrictTopK = true
+	round := memory.DefaultConfig()
+	round.TopKRounding = memory.Round
+
+	tests := []struct {
+		name string
+		cfg  memory.Config
+		// want maps each top_k to the expected result count, or -1 for an
+		// error.
+		want map[float64]int
+	}{
+		{name: "truncate", cfg: memory.DefaultConfig(), want: map[float64]int{7.9: 7, 7.1: 7, 7.0: 7}},
+		{name: "round", cfg: round, want: map[float64]int{7.9: 8, 7.1: 7, 7.0: 7}},
+		{name: "strict", cfg: strict, want: map[float64]int{7.9: -1, 7.1: -1, 7.0: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewMockVectorIndex()
+			for i := range 10 {
+				index.UpsertData(ctx, vector.UpsertData{Id: fmt.Sprintf("item-%d", i), Data: "an item"})
+			}
+
+			srv := createMemoryMCPServerWith(t, index, tt.cfg)
+			defer srv.Close()
+			if err := srv.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			for topK, want := range tt.want {
+				got, err := callTool(ctx, srv.Client(), "search-memory", map[string]any{"query": "item", "top_k": topK})
+				if want < 0 {
+					if err == nil {
+						t.Errorf("top_k %g: expected error but got %q", topK, got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("top_k %g: CallTool: %v", topK, err)
+				}
+				if prefix := fmt.Sprintf("Found %d memories:", want); !strings.HasPrefix(got, prefix) {
+					t.Errorf("top_k %g: expected %q, got: %s", topK, prefix, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchMemoryDefaultTopK(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("DEFAULT_TOP_K", "3")
+	cfg, err := memory.ConfigFromEnv()
+	if err != nil {
+		t.Fatal("ConfigFromEnv:", err)
+	}
+	if cfg.DefaultTopK != 3 {
+		t.Fatalf("Got DefaultTopK %d, want 3", cfg.DefaultTopK)
+	}
+
+	index := NewMockVectorIndex()
+	for i := range 10 {
+		index.UpsertData(ctx, vector.UpsertData{Id: fmt.Sprintf("item-%d", i), Data: "an item"})
+	}
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(ctx, srv.Client(), "search-memory", map[string]any{"query": "item"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Found 3 memories:") {
+		t.Errorf("Expected the env default without top_k, got: %s", got)
+	}
+
+	got, err = callTool(ctx, srv.Client(), "search-memory", map[string]any{"query": "item", "top_k": 7})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Found 7 memories:") {
+		t.Errorf("Expected top_k to override the env default, got: %s", got)
+	}
+
+	for _, raw := range []string{"0", "-2", "many"} {
+		t.Setenv("DEFAULT_TOP_K", raw)
+		if _, err := memory.ConfigFromEnv(); err == nil {
+			t.Errorf("DEFAULT_TOP_K=%s: expected error but got none", raw)
+		}
+	}
+}
+
+func TestSearchMemoryDiversity(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := []struct {
+		id      string
+		content string
+		score   float32
+	}{
+		{"roast-1", "coffee dark roast espresso beans", 0.95},
+		{"roast-2", "coffee dark roast espresso shots", 0.94},
+		{"roast-3", "coffee dark roast espresso blend", 0.93},
+		{"decaf", "coffee after noon keeps me awake", 0.90},
+	}
+	for _, m := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": m.id, "content": m.content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		index.SetScore(m.id, m.score)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		ids  []string
+	}{
+		{name: "plain similarity", args: map[string]any{}, ids: []string{"roast-1", "roast-2"}},
+		{name: "diversity", args: map[string]any{"diversity": true}, ids: []string{"roast-1", "decaf"}},
+		{name: "diversity with strong novelty", args: map[string]any{"diversity": true, "diversity_lambda": 0.2}, ids: []string{"roast-1", "decaf"}},
+		{name: "diversity with relevance only", args: map[string]any{"diversity": true, "diversity_lambda": 1}, ids: []string{"roast-1", "roast-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"query": "coffee", "top_k": 2}
+			maps.Copy(args, tt.args)
+			got, err := callTool(ctx, client, "search-memory", args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if !strings.HasPrefix(got, "Found 2 memories:") {
+				t.Errorf("Expected top_k results after reranking, got: %s", got)
+			}
+			assertIDOrder(t, got, tt.ids)
+		})
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "top_k": 2, "diversity": true, "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Contains(got, `"vector"`) {
+		t.Errorf("Expected the vectors fetched for reranking to stay out of the output, got: %s", got)
+	}
+
+	if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "diversity": true, "diversity_lambda": 1.5}); err == nil {
+		t.Error("Expected error for diversity_lambda above 1 but got none")
+	}
+	for _, topK := range []any{-1, 0, "-1"} {
+		if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "top_k": topK, "diversity": true}); err == nil {
+			t.Errorf("Expected error for top_k %v with diversity but got none", topK)
+		}
+	}
+}
+
+func TestSearchMemoryExplain(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "tea", "content": "Prefers green tea in the morning"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+	index.SetScore("tea", 0.8123)
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "green tea"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Contains(got, "Why:") {
+		t.Errorf("Expected no explanation by default, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "green tea", "explain": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	for _, want := range []string{
+		"Why: semantic similarity 0.8123 to 'green tea'",
+		"no filter or tag constraints applied",
+		"content contains query terms: green, tea",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in explanation, got: %s", want, got)
+		}
+	}
+
+	var results []memory.MemoryResult
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "green tea", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", got, err)
+	}
+	if len(results) != 1 || results[0].Explain != nil || results[0].Why != "" {
+		t.Errorf("Expected no explain fields by default, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "green tea", "format": "json", "explain": true, "preview_chars": 5})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	results = nil
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", got, err)
+	}
+	want := memory.Explanation{RawScore: 0.8123, Query: "green tea", ContentLength: len("Prefers green tea in the morning")}
+	if len(results) != 1 || results[0].Explain == nil || *results[0].Explain != want {
+		t.Errorf("Expected explain %+v, got: %s", want, got)
+	}
+}
+
+// assertIDOrder checks that each ID appears in output in the given order.
+func assertIDOrder(t *testing.T, output string, ids []string) {
+	t.Helper()
+
+	last := -1
+	for _, id := range ids {
+		pos := strings.Index(output, "ID: "+id+",")
+		if pos < 0 {
+			t.Fatalf("ID %q missing from output: %s", id, output)
+		}
+		if pos < last {
+			t.Fatalf("ID %q out of order in output: %s", id, output)
+		}
+		last = pos
+	}
+}
+
+func TestGetMemory(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	var addReq mcp.CallToolRequest
+	addReq.Params.Name = "add-to-memory"
+	addReq.Params.Arguments = map[string]any{
+		"id":      "test-memory",
+		"content": "This is a specific test memory",
+	}
+	_, err = client.CallTool(ctx, addReq)
+	if err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	var getReq mcp.CallToolRequest
+	getReq.Params.Name = "get-memory"
+	getReq.Params.Arguments = map[string]any{
+		"id": "test-memory",
+	}
+
+	result, err := client.CallTool(ctx, getReq)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	got, err := resultToString(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(got, "Memory ID: test-memory") {
+		t.Errorf("Expected memory ID in response, got: %s", got)
+	}
+	if !strings.Contains(got, "This is a specific test memory") {
+		t.Errorf("Expected memory content in response, got: %s", got)
+	}
+}
+
+// TestGetMemoryExactID checks that get-memory looks memories up by ID, so
+// one is found even when a semantic query for the ID text would not
+// surface it.
+func TestMemoryJSONFormat(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, score := range map[string]float32{"espresso": 0.9, "latte": 0.7} {
+		args := map[string]any{"id": id, "content": id + " coffee order", "metadata": map[string]any{"size": "small"}}
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		index.SetScore(id, score)
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	var results []memory.MemoryResult
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Expected a JSON array, got %q: %v", got, err)
+	}
+	if len(results) != 2 || results[0].ID != "espresso" || results[1].ID != "latte" {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	if results[0].Score == nil || *results[0].Score != 0.9 {
+		t.Errorf("Expected score 0.9, got %v", results[0].Score)
+	}
+	if results[0].Content != "espresso coffee order" {
+		t.Errorf("Got content %q", results[0].Content)
+	}
+	if !reflect.DeepEqual(results[0].Metadata, map[string]any{"size": "small"}) {
+		t.Errorf("Expected only caller metadata, got %v", results[0].Metadata)
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "tea", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "[]" {
+		t.Errorf("Expected an empty array for no results, got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "latte", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	results = nil
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Expected a JSON array, got %q: %v", got, err)
+	}
+	if len(results) != 1 || results[0].ID != "latte" || results[0].Content != "latte coffee order" || results[0].Score != nil {
+		t.Errorf("Unexpected get-memory result: %+v", results)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "mocha", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "[]" {
+		t.Errorf("Expected an empty array for a missing memory, got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "latte"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Memory ID: latte\n") {
+		t.Errorf("Expected text by default, got %q", got)
+	}
+
+	if _, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "format": "xml"}); err == nil {
+		t.Error("Expected error for an unknown format but got none")
+	}
+}
+
+func TestSearchMemoryIncludeVectors(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "espresso", "content": "espresso coffee order"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	search := func(args map[string]any) []memory.MemoryResult {
+		t.Helper()
+		got, err := callTool(ctx, client, "search-memory", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		var results []memory.MemoryResult
+		if err := json.Unmarshal([]byte(got), &results); err != nil {
+			t.Fatalf("Expected a JSON array, got %q: %v", got, err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("Unexpected results: %+v", results)
+		}
+		return results
+	}
+
+	results := search(map[string]any{"query": "coffee", "format": "json", "include_vectors": true})
+	if want := mockEmbed("espresso coffee order"); !reflect.DeepEqual(results[0].Vector, want) {
+		t.Errorf("Got vector %v, want %v", results[0].Vector, want)
+	}
+
+	results = search(map[string]any{"query": "coffee", "format": "json"})
+	if results[0].Vector != nil {
+		t.Errorf("Expected no vector by default, got %v", results[0].Vector)
+	}
+
+	got, err := callTool(ctx, client, "search-memory", map[string]any{"query": "coffee", "include_vectors": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Contains(got, "vector") || strings.Contains(got, "Vector") {
+		t.Errorf("Expected text output without vectors, got %q", got)
+	}
+}
+
+func TestGetMemories(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, args := range []map[string]any{
+		{"id": "alpha", "content": "first memory", "metadata": map[string]any{"topic": "go"}},
+		{"id": "beta", "content": "second memory"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "get-memories", map[string]any{"ids": []any{"alpha", "missing", "beta", "alpha"}})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	var results map[string]*struct {
+		ID       string         `json:"id"`
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Result is not a JSON object: %v: %s", err, got)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected one entry per distinct ID, got: %s", got)
+	}
+	if missing, exists := results["missing"]; !exists || missing != nil {
+		t.Errorf("Expected the missing ID to map to null, got: %s", got)
+	}
+	if alpha := results["alpha"]; alpha == nil || alpha.Content != "first memory" || alpha.Metadata["topic"] != "go" {
+		t.Errorf("Unexpected alpha entry in: %s", got)
+	}
+	if beta := results["beta"]; beta == nil || beta.ID != "beta" || beta.Content != "second memory" {
+		t.Errorf("Unexpected beta entry in: %s", got)
+	}
+
+	if got, err := callTool(ctx, client, "get-memories", map[string]any{"ids": `["beta"]`}); err != nil || !strings.Contains(got, `"beta":{"id":"beta"`) {
+		t.Errorf("Expected a JSON string of IDs to be accepted, got %s, %v", got, err)
+	}
+
+	for _, ids := range []any{[]any{}, []any{"alpha", 7}, "alpha", nil} {
+		if _, err := callTool(ctx, client, "get-memories", map[string]any{"ids": ids}); err == nil {
+			t.Errorf("ids %v: expected error but got none", ids)
+		}
+	}
+}
+
+func TestGetMemoryExactID(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, content := range map[string]string{
+		"pref-42":  "Prefers dark roast coffee",
+		"pref-421": "Contains the text pref-42 but is another memory",
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "pref-42"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	got = withoutTimestamps(got)
+	if expected := "Memory ID: pref-42\nContent: Prefers dark roast coffee"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+}
+
+func TestGetMemoryIncludeVector(t *testing.T) {
+	ctx := context.Background()
+	cfg := memory.DefaultConfig()
+	cfg.VectorPreviewDims = 8
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "vec", "content": "remember the vector"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "vec"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Contains(got, "Vector") {
+		t.Errorf("Expected no vector by default, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "vec", "include_vector": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Vector (64 dims): [") {
+		t.Errorf("Expected vector in response, got: %s", got)
+	}
+	if !strings.Contains(got, "... 56 more]") {
+		t.Errorf("Expected vector capped at 8 dims, got: %s", got)
+	}
+}
+
+func TestGetMemoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	var getReq mcp.CallToolRequest
+	getReq.Params.Name = "get-memory"
+	getReq.Params.Arguments = map[string]any{
+		"id": "nonexistent",
+	}
+
+	result, err := client.CallTool(ctx, getReq)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	got, err := resultToString(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Memory with ID 'nonexistent' not found"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+}
+
+func TestGetMemorySuggestOnMiss(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "fav-color", "content": "My favorite color is blue"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+	}{
+		{
+			name:     "hit ignores the flag",
+			args:     map[string]any{"id": "fav-color", "suggest_on_miss": true},
+			expected: "Memory ID: fav-color\nContent: My favorite color is blue",
+		},
+		{
+			name:     "miss with suggestion",
+			args:     map[string]any{"id": "favorite color", "suggest_on_miss": true},
+			expected: "Memory with ID 'favorite color' not found\nDid you mean: fav-color (score: 0.9500)\nContent: My favorite color is blue",
+		},
+		{
+			name:     "miss with no suggestion available",
+			args:     map[string]any{"id": "quarterly taxes", "suggest_on_miss": true},
+			expected: "Memory with ID 'quarterly taxes' not found",
+		},
+		{
+			name:     "miss without the flag",
+			args:     map[string]any{"id": "favorite color"},
+			expected: "Memory with ID 'favorite color' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "get-memory", tt.args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got := withoutTimestamps(got); got != tt.expected {
+				t.Errorf("Got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareMemories(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := map[string]string{
+		"cats":       "I love cats and kittens",
+		"cats-again": "I love cats and kittens",
+		"taxes":      "quarterly revenue filing deadline",
+	}
+	for id, content := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		idA, idB string
+		minScore float64
+		maxScore float64
+		label    string
+	}{
+		{name: "identical content", idA: "cats", idB: "cats-again", minScore: 0.999, maxScore: 1.001, label: "highly similar"},
+		{name: "unrelated content", idA: "cats", idB: "taxes", minScore: -0.001, maxScore: 0.3, label: "dissimilar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "compare-memories", map[string]any{"id_a": tt.idA, "id_b": tt.idB})
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+
+			var score float64
+			prefix := fmt.Sprintf("Similarity between '%s' and '%s': ", tt.idA, tt.idB)
+			if _, err := fmt.Sscanf(strings.TrimPrefix(got, prefix), "%f", &score); err != nil {
+				t.Fatalf("could not parse score from %q: %v", got, err)
+			}
+			if score < tt.minScore || score > tt.maxScore {
+				t.Errorf("score = %.4f, want between %.3f and %.3f", score, tt.minScore, tt.maxScore)
+			}
+			if !strings.Contains(got, tt.label) {
+				t.Errorf("Expected label %q, got: %s", tt.label, got)
+			}
+		})
+	}
+
+	if _, err := callTool(ctx, client, "compare-memories", map[string]any{"id_a": "cats", "id_b": "missing"}); err == nil {
+		t.Error("Expected error for missing ID but got none")
+	}
+}
+
+func TestCompareMemoriesKnownVectors(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	index.data["x-axis"] = mockRecord{data: "along x", vector: []float32{1, 0, 0}}
+	index.data["diagonal"] = mockRecord{data: "between x and y", vector: []float32{1, 1, 0}}
+	index.data["opposite"] = mockRecord{data: "against x", vector: []float32{-2, 0, 0}}
+	index.data["unembedded"] = mockRecord{data: "no vector"}
+
+	tests := []struct {
+		idA, idB string
+		expected string
+	}{
+		{idA: "x-axis", idB: "diagonal", expected: "Similarity between 'x-axis' and 'diagonal': 0.7071 (somewhat similar)"},
+		{idA: "x-axis", idB: "opposite", expected: "Similarity between 'x-axis' and 'opposite': -1.0000 (dissimilar)"},
+		{idA: "diagonal", idB: "diagonal", expected: "Similarity between 'diagonal' and 'diagonal': 1.0000 (highly similar)"},
+	}
+	for _, tt := range tests {
+		got, err := callTool(ctx, client, "compare-memories", map[string]any{"id_a": tt.idA, "id_b": tt.idB})
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		if got != tt.expected {
+			t.Errorf("Got %q, want %q", got, tt.expected)
+		}
+	}
+
+	for _, tt := range []struct {
+		idA, idB string
+		want     string
+	}{
+		{idA: "x-axis", idB: "missing", want: "memory with ID 'missing' not found"},
+		{idA: "unembedded", idB: "x-axis", want: "memory with ID 'unembedded' has no stored vector"},
+	} {
+		_, err := callTool(ctx, client, "compare-memories", map[string]any{"id_a": tt.idA, "id_b": tt.idB})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected error %q, got: %v", tt.want, err)
+		}
+	}
+}
+
+func TestMemoryGraph(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := map[string]string{
+		"cats":       "I love cats and kittens",
+		"cats-again": "I love cats and kittens",
+		"cats-dogs":  "I love cats and dogs",
+		"taxes":      "quarterly revenue filing deadline",
+	}
+	for id, content := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	graphOf := func(t *testing.T, args map[string]any) memory.Graph {
+		t.Helper()
+		got, err := callTool(ctx, client, "memory-graph", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		var graph memory.Graph
+		if err := json.Unmarshal([]byte(got), &graph); err != nil {
+			t.Fatalf("invalid JSON %q: %v", got, err)
+		}
+		return graph
+	}
+
+	t.Run("edges only above threshold", func(t *testing.T) {
+		const threshold = 0.7
+		graph := graphOf(t, map[string]any{"threshold": threshold})
+
+		if len(graph.Nodes) != len(seed) || graph.Truncated {
+			t.Fatalf("Expected %d untruncated nodes, got %+v", len(seed), graph)
+		}
+		neighbors := make(map[string][]string)
+		for _, node := range graph.Nodes {
+			for _, n := range node.Neighbors {
+				if n.Score < threshold {
+					t.Errorf("Edge %s-%s has score %.4f below threshold", node.ID, n.ID, n.Score)
+				}
+				neighbors[node.ID] = append(neighbors[node.ID], n.ID)
+			}
+		}
+		if got := neighbors["cats"]; len(got) != 2 || got[0] != "cats-again" {
+			t.Errorf("cats neighbors = %v, want cats-again first then cats-dogs", got)
+		}
+		if got := neighbors["taxes"]; len(got) != 0 {
+			t.Errorf("taxes neighbors = %v, want none", got)
+		}
+	})
+
+	t.Run("higher threshold drops weaker edges", func(t *testing.T) {
+		graph := graphOf(t, map[string]any{"threshold": 0.99})
+		for _, node := range graph.Nodes {
+			switch node.ID {
+			case "cats", "cats-again":
+				if len(node.Neighbors) != 1 {
+					t.Errorf("%s neighbors = %+v, want only its duplicate", node.ID, node.Neighbors)
+				}
+			default:
+				if len(node.Neighbors) != 0 {
+					t.Errorf("%s neighbors = %+v, want none", node.ID, node.Neighbors)
+				}
+			}
+		}
+	})
+
+	t.Run("max_nodes caps the sample", func(t *testing.T) {
+		graph := graphOf(t, map[string]any{"max_nodes": 2})
+		if len(graph.Nodes) != 2 || !graph.Truncated {
+			t.Errorf("Expected 2 nodes and truncated, got %+v", graph)
+		}
+	})
+
+	if _, err := callTool(ctx, client, "memory-graph", map[string]any{"threshold": 2}); err == nil {
+		t.Error("Expected error for out-of-range threshold but got none")
+	}
+}
+
+// failingDeleteIndex fails every batch delete that includes failID.
+type failingDeleteIndex struct {
+	*MockVectorIndex
+	failID string
+}
+
+func (f *failingDeleteIndex) DeleteMany(ctx context.Context, ids []string) (int, error) {
+	if slices.Contains(ids, f.failID) {
+		return 0, fmt.Errorf("upstash: internal error")
+	}
+	return f.MockVectorIndex.DeleteMany(ctx, ids)
+}
+
+func TestDeleteMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.ChunkSize = 10
+	cfg.ChunkOverlap = 2
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, content := range map[string]string{
+		"a":    "short one",
+		"b":    "short two",
+		"long": "a memory long enough to be stored in chunks",
+		"keep": "left alone",
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "delete-memories", map[string]any{"ids": []any{"a", "missing", "long", "b", "gone"}})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Deleted 3 of 5 memories\nNot found: missing, gone"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if ids := slices.Sorted(maps.Keys(index.data)); !slices.Equal(ids, []string{"keep"}) {
+		t.Errorf("Expected only 'keep' left, got %v", ids)
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"ids": []any{}},
+		{"ids": []any{"keep", 3}},
+	} {
+		if _, err := callTool(ctx, client, "delete-memories", args); err == nil {
+			t.Errorf("Expected error for %v but got none", args)
+		}
+	}
+}
+
+func TestDeleteMemoriesDryRun(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.ChunkSize = 10
+	cfg.ChunkOverlap = 2
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, content := range map[string]string{
+		"a":    "short one",
+		"long": "a memory long enough to be stored in chunks",
+		"keep": "left alone",
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	before := maps.Clone(index.data)
+
+	got, err := callTool(ctx, client, "delete-memories", map[string]any{"ids": []any{"a", "missing", "long"}, "dry_run": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Dry run: would delete 2 of 3 memories\nWould delete: a, long\nNot found: missing"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if !maps.EqualFunc(index.data, before, func(a, b mockRecord) bool { return a.data == b.data }) {
+		t.Errorf("Dry run changed the store: had %v, now %v", slices.Sorted(maps.Keys(before)), slices.Sorted(maps.Keys(index.data)))
+	}
+}
+
+func TestDeleteMemoriesPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	index := &failingDeleteIndex{MockVectorIndex: NewMockVectorIndex(), failID: "m042"}
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	// Enough memories for two batches, the first of which fails.
+	var ids []any
+	for i := range 150 {
+		id := fmt.Sprintf("m%03d", i)
+		ids = append(ids, id)
+		if err := index.UpsertData(ctx, vector.UpsertData{Id: id, Data: "memory " + id}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "delete-memories", map[string]any{"ids": ids})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Deleted 50 of 150 memories\nFailed: m000 (upstash: internal error), ") {
+		t.Errorf("Expected a summary of the partial failure, got: %.200s", got)
+	}
+	if strings.Count(got, "(upstash: internal error)") != 100 {
+		t.Errorf("Expected the 100 memories of the failed batch listed, got: %s", got)
+	}
+	if len(index.data) != 100 {
+		t.Errorf("Expected the failed batch left in the index, got %d records", len(index.data))
+	}
+}
+
+func TestAddToMemorySkipUnchanged(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.ChunkSize = 10
+	cfg.ChunkOverlap = 2
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+		upserts  int
+	}{
+		{
+			name:     "first add is stored",
+			args:     map[string]any{"id": "pet", "content": "I have a cat", "skip_unchanged": true},
+			expected: "Successfully stored memory with ID: pet",
+			upserts:  1,
+		},
+		{
+			name:     "identical re-add is skipped",
+			args:     map[string]any{"id": "pet", "content": "I have a cat", "skip_unchanged": true},
+			expected: "No change to memory with ID: pet",
+		},
+		{
+			name:     "identical re-add without the flag is stored",
+			args:     map[string]any{"id": "pet", "content": "I have a cat"},
+			expected: "Successfully stored memory with ID: pet",
+			upserts:  1,
+		},
+		{
+			name:     "changed content is stored",
+			args:     map[string]any{"id": "pet", "content": "I have a dog", "skip_unchanged": true},
+			expected: "Successfully stored memory with ID: pet",
+			upserts:  1,
+		},
+		{
+			name:     "changed metadata is stored",
+			args:     map[string]any{"id": "pet", "content": "I have a dog", "metadata": map[string]any{"since": 2020}, "skip_unchanged": true},
+			expected: "Successfully stored memory with ID: pet",
+			upserts:  1,
+		},
+		{
+			name:     "chunked add is stored",
+			args:     map[string]any{"id": "long", "content": "abcdefghijklmnopqrstuvwxyz", "chunk": true},
+			expected: "Successfully stored memory with ID: long in 3 chunks",
+			upserts:  3,
+		},
+		{
+			name:     "chunked re-add is skipped",
+			args:     map[string]any{"id": "long", "content": "abcdefghijklmnopqrstuvwxyz", "chunk": true, "skip_unchanged": true},
+			expected: "No change to memory with ID: long",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := index.upserts
+			got, err := callTool(ctx, client, "add-to-memory", tt.args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Got %q, want %q", got, tt.expected)
+			}
+			if n := index.upserts - before; n != tt.upserts {
+				t.Errorf("Got %d upserts, want %d", n, tt.upserts)
+			}
+		})
+	}
+}
+
+func TestBatchAddMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	result, err := client.CallTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "batch-add-memories",
+			Arguments: map[string]any{
+				"memories": []any{
+					map[string]any{"id": "pet", "content": "I have a cat", "metadata": map[string]any{"category": "pets"}},
+					map[string]any{"id": "food", "content": "I like ramen"},
+					map[string]any{"id": "empty"},
+					map[string]any{"content": "no id here"},
+					map[string]any{"id": "pet", "content": "I have a dog"},
+					map[string]any{"id": "bad", "content": "x", "metadata": map[string]any{"content_hash": "forged"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if result.IsError {
+		t.Error("Expected partial success not to be an error")
+	}
+
+	got, err := resultToString(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `Stored 2 of 6 memories
+Failed validation (4):
+- empty: missing content
+- #3: missing id
+- pet: duplicate id in batch
+- bad: metadata key 'content_hash' is reserved`
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	if len(index.data) != 2 || index.data["pet"].data != "I have a cat" || index.data["food"].data != "I like ramen" {
+		t.Errorf("Expected only the valid memories stored, got %v", index.data)
+	}
+	if index.data["pet"].metadata["category"] != "pets" {
+		t.Errorf("Expected metadata stored, got %v", index.data["pet"].metadata)
+	}
+	if index.upserts != 2 {
+		t.Errorf("Got %d upserts, want 2", index.upserts)
+	}
+
+	if _, err := callTool(ctx, client, "batch-add-memories", map[string]any{"memories": "not json"}); err == nil {
+		t.Error("Expected error for a malformed batch but got none")
+	}
+}
+
+func TestUpdateMemory(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	_, err = callTool(ctx, client, "add-to-memory", map[string]any{
+		"id":       "pet",
+		"content":  "I have a cat",
+		"metadata": map[string]any{"category": "pets", "since": 2020},
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	createdAt := index.data["pet"].metadata["created_at"]
+
+	got, err := callTool(ctx, client, "update-memory", map[string]any{"id": "pet", "content": "I have a dog"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Successfully updated content of memory with ID: pet"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	record := index.data["pet"]
+	if record.data != "I have a dog" {
+		t.Errorf("Expected content updated, got %q", record.data)
+	}
+	if record.metadata["category"] != "pets" || record.metadata["since"] != float64(2020) {
+		t.Errorf("Expected metadata retained, got %v", record.metadata)
+	}
+	if record.metadata["created_at"] != createdAt {
+		t.Errorf("Expected created_at %v retained, got %v", createdAt, record.metadata["created_at"])
+	}
+
+	got, err = callTool(ctx, client, "update-memory", map[string]any{
+		"id":       "pet",
+		"metadata": map[string]any{"name": "Rex", "since": nil},
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Successfully updated metadata of memory with ID: pet"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "pet"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	got = withoutTimestamps(got)
+	if expected := `Memory ID: pet
+Content: I have a dog
+Metadata: {"category":"pets","name":"Rex"}`; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	before := index.upserts
+	got, err = callTool(ctx, client, "update-memory", map[string]any{"id": "pet", "content": "I have a dog"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "No change to memory with ID: pet"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if index.upserts != before {
+		t.Error("Expected an unchanged update to skip the write")
+	}
+
+	for name, args := range map[string]map[string]any{
+		"missing ID":     {"id": "ghost", "content": "boo"},
+		"nothing to set": {"id": "pet"},
+		"reserved key":   {"id": "pet", "metadata": map[string]any{"content_hash": "forged"}},
+	} {
+		_, err := callTool(ctx, client, "update-memory", args)
+		if err == nil {
+			t.Errorf("%s: expected error but got none", name)
+			continue
+		}
+		if name == "missing ID" && !strings.Contains(err.Error(), "use add-to-memory") {
+			t.Errorf("Expected a pointer to add-to-memory, got: %v", err)
+		}
+	}
+	if _, exists := index.data["ghost"]; exists {
+		t.Error("update-memory created a missing memory")
+	}
+}
+
+func TestUpdateMemoryMetadata(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	content := "Café notes:\n\ttabs, ünïcode and trailing space "
+	_, err = callTool(ctx, client, "add-to-memory", map[string]any{
+		"id":       "notes",
+		"content":  content,
+		"metadata": map[string]any{"category": "food", "since": 2020},
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	before := index.data["notes"]
+
+	got, err := callTool(ctx, client, "update-memory-metadata", map[string]any{
+		"id":       "notes",
+		"metadata": map[string]any{"category": "travel", "rating": 5},
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Successfully replaced metadata of memory with ID: notes"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	record := index.data["notes"]
+	if record.data != content || record.data != before.data {
+		t.Errorf("Expected content unchanged byte for byte, got %q", record.data)
+	}
+	if !reflect.DeepEqual(record.vector, before.vector) {
+		t.Error("Expected the vector to be kept")
+	}
+	if record.metadata["category"] != "travel" || record.metadata["rating"] != float64(5) {
+		t.Errorf("Expected new metadata, got %v", record.metadata)
+	}
+	if _, exists := record.metadata["since"]; exists {
+		t.Errorf("Expected keys not given to be removed, got %v", record.metadata)
+	}
+	if record.metadata["created_at"] != before.metadata["created_at"] {
+		t.Errorf("Expected created_at %v retained, got %v", before.metadata["created_at"], record.metadata["created_at"])
+	}
+
+	for name, args := range map[string]map[string]any{
+		"missing ID":       {"id": "ghost", "metadata": map[string]any{"a": 1}},
+		"missing metadata": {"id": "notes"},
+		"reserved key":     {"id": "notes", "metadata": map[string]any{"content_hash": "forged"}},
+	} {
+		_, err := callTool(ctx, client, "update-memory-metadata", args)
+		if err == nil {
+			t.Errorf("%s: expected error but got none", name)
+			continue
+		}
+		if name == "missing ID" && !strings.Contains(err.Error(), "not found") {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	}
+	if _, exists := index.data["ghost"]; exists {
+		t.Error("update-memory-metadata created a missing memory")
+	}
+}
+
+func TestSimilarityThresholdDefault(t *testing.T) {
+	ctx := context.Background()
+	cfg := memory.DefaultConfig()
+	cfg.SimilarityThreshold = 0.99
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := map[string]string{
+		"cats":       "I love cats and kittens",
+		"cats-again": "I love cats and kittens",
+		"cats-dogs":  "I love cats and dogs",
+	}
+	for id, content := range seed {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	edges := func(t *testing.T, args map[string]any) int {
+		t.Helper()
+		got, err := callTool(ctx, client, "memory-graph", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		var graph memory.Graph
+		if err := json.Unmarshal([]byte(got), &graph); err != nil {
+			t.Fatalf("invalid JSON %q: %v", got, err)
+		}
+		n := 0
+		for _, node := range graph.Nodes {
+			n += len(node.Neighbors)
+		}
+		return n / 2
+	}
+
+	if n := edges(t, map[string]any{}); n != 1 {
+		t.Errorf("With the configured default, got %d edges, want only the duplicate pair", n)
+	}
+	if n := edges(t, map[string]any{"threshold": 0.7}); n != 3 {
+		t.Errorf("With a per-call override, got %d edges, want 3", n)
+	}
+}
+
+func TestListMemories(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	got, err := callTool(ctx, client, "list-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "No memories stored yet" {
+		t.Errorf("Got %q, want %q", got, "No memories stored yet")
+	}
+
+	long := strings.Repeat("x", 100)
+	for i := range 5 {
+		content := fmt.Sprintf("memory %d", i)
+		if i == 0 {
+			content = long
+		}
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": fmt.Sprintf("m%d", i), "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	first, err := callTool(ctx, client, "list-memories", map[string]any{"limit": 3})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(first, "Content: "+strings.Repeat("x", 80)+"...\n") {
+		t.Errorf("Expected truncated preview, got: %s", first)
+	}
+
+	_, cursor, found := strings.Cut(first, "Next cursor: ")
+	if !found {
+		t.Fatalf("Expected a next cursor, got: %s", first)
+	}
+
+	second, err := callTool(ctx, client, "list-memories", map[string]any{"cursor": cursor, "limit": 3})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasSuffix(second, "No more memories") {
+		t.Errorf("Expected last page, got: %s", second)
+	}
+
+	seen := make(map[string]int)
+	for _, page := range []string{first, second} {
+		for _, line := range strings.Split(page, "\n") {
+			if _, rest, ok := strings.Cut(line, "ID: "); ok {
+				id, _, _ := strings.Cut(rest, ",")
+				seen[id]++
+			}
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 distinct IDs across pages, got %v", seen)
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("ID %s appeared on %d pages", id, n)
+		}
+	}
+
+	if _, err := callTool(ctx, client, "list-memories", map[string]any{"limit": 0}); err == nil {
+		t.Error("Expected error for zero limit but got none")
+	}
+}
+
+func TestFindMemoryByID(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, content := range map[string]string{
+		"note-alpha": "First note about the alpha release",
+		"note-beta":  "Second note about the beta release",
+		"shopping":   "Buy oat milk",
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": content}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "find-memory-by-id", map[string]any{"id": "note-alfa"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "1. ID: note-alpha, Distance: 2, Content: First note about the alpha release\n") {
+		t.Errorf("Expected note-alpha as the closest match, got %q", got)
+	}
+	if strings.Contains(got, "shopping") {
+		t.Errorf("Expected unrelated IDs to be left out, got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "find-memory-by-id", map[string]any{"id": "NOTE-ALFA", "max_distance": 2})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Found 1 memories with IDs near 'NOTE-ALFA':\n1. ID: note-alpha,") {
+		t.Errorf("Expected a case-insensitive match within distance 2, got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "find-memory-by-id", map[string]any{"id": "note-", "max_distance": 5, "limit": 1})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Found 1 memories") {
+		t.Errorf("Expected limit to cap the matches, got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "find-memory-by-id", map[string]any{"id": "recipes", "max_distance": 1})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "No memory IDs within distance 1 of 'recipes'" {
+		t.Errorf("Got %q", got)
+	}
+
+	for _, args := range []map[string]any{
+		{"id": " "},
+		{"id": "note", "max_distance": -1},
+		{"id": "note", "limit": 0},
+	} {
+		if _, err := callTool(ctx, client, "find-memory-by-id", args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
+
+func TestListMemoriesSorted(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	// Metadata arrives from Upstash as decoded JSON, so numbers are float64.
+	seed := []vector.UpsertData{
+		{Id: "low", Data: "low priority", Metadata: map[string]any{"priority": float64(1), "owner": "carol"}},
+		{Id: "high", Data: "high priority", Metadata: map[string]any{"priority": float64(10), "owner": "alice"}},
+		{Id: "mid", Data: "mid priority", Metadata: map[string]any{"priority": float64(2)}},
+		{Id: "none", Data: "no metadata"},
+	}
+	for _, d := range seed {
+		if err := index.UpsertData(ctx, d); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		ids  []string
+	}{
+		{name: "numeric ascending", args: map[string]any{"field": "priority"}, ids: []string{"low", "mid", "high", "none"}},
+		{name: "numeric descending", args: map[string]any{"field": "priority", "order": "desc"}, ids: []string{"high", "mid", "low", "none"}},
+		{name: "string ascending", args: map[string]any{"field": "owner", "order": "asc"}, ids: []string{"high", "low", "mid", "none"}},
+		{name: "string descending", args: map[string]any{"field": "owner", "order": "desc"}, ids: []string{"low", "high", "mid", "none"}},
+		{name: "limit", args: map[string]any{"field": "priority", "limit": 2}, ids: []string{"low", "mid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "list-memories-sorted", tt.args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			assertIDOrder(t, got, tt.ids)
+			if strings.Count(got, "ID: ") != len(tt.ids) {
+				t.Errorf("Expected %d memories, got: %s", len(tt.ids), got)
+			}
+		})
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"field": " "},
+		{"field": "priority", "order": "sideways"},
+		{"field": "priority", "limit": 0},
+	} {
+		if _, err := callTool(ctx, client, "list-memories-sorted", args); err == nil {
+			t.Errorf("Expected error for %v but got none", args)
+		}
+	}
+}
+
+func TestRecentMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := memory.DefaultConfig()
+	cfg.Now = func() time.Time { return now }
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	got, err := callTool(ctx, client, "recent-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "No memories stored"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	// Stored out of ID order an hour apart, so only created_at explains
+	// the ordering.
+	for _, id := range []string{"beta", "delta", "alpha", "gamma"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": id + " memory"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+		now = now.Add(time.Hour)
+	}
+	// A legacy record from before timestamps were stamped.
+	if err := index.UpsertData(ctx, vector.UpsertData{Id: "legacy", Data: "legacy memory"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	got, err = callTool(ctx, client, "recent-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	assertIDOrder(t, got, []string{"gamma", "alpha", "delta", "beta", "legacy"})
+	if !strings.Contains(got, "ID: gamma, created_at: 2024-03-01T15:00:00Z") || !strings.Contains(got, "ID: legacy, created_at: (missing)") {
+		t.Errorf("Expected created_at of each memory, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "recent-memories", map[string]any{"limit": 2})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	assertIDOrder(t, got, []string{"gamma", "alpha"})
+	if strings.Count(got, "ID: ") != 2 {
+		t.Errorf("Expected 2 memories, got: %s", got)
+	}
+
+	for _, args := range []map[string]any{
+		{"limit": 0},
+		{"limit": "3"},
+	} {
+		if _, err := callTool(ctx, client, "recent-memories", args); err == nil {
+			t.Errorf("Expected error for %v but got none", args)
+		}
+	}
+}
+
+func TestFacetMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := []vector.UpsertData{
+		{Id: "w1", Data: "standup", Metadata: map[string]any{"category": "work"}},
+		{Id: "w2", Data: "review", Metadata: map[string]any{"category": "work"}},
+		{Id: "w3", Data: "deploy", Metadata: map[string]any{"category": "work"}},
+		{Id: "w4", Data: "retro", Metadata: map[string]any{"category": "work"}},
+		{Id: "h1", Data: "groceries", Metadata: map[string]any{"category": "home"}},
+		{Id: "p1", Data: "birthday", Metadata: map[string]any{"category": "personal"}},
+		{Id: "p2", Data: "dentist", Metadata: map[string]any{"category": "personal"}},
+		{Id: "n1", Data: "uncategorized"},
+	}
+	for _, d := range seed {
+		if err := index.UpsertData(ctx, d); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "facet-memories", map[string]any{"field": "category"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	expected := "Facets for 'category' across 8 memories:\n" +
+		"- work: 4 (e.g. w1, w2, w3)\n" +
+		"- personal: 2 (e.g. p1, p2)\n" +
+		"- home: 1 (e.g. h1)\n" +
+		"- (unset): 1 (e.g. n1)\n"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "facet-memories", map[string]any{"field": "category", "max_values": 1})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	expected = "Facets for 'category' across 8 memories:\n" +
+		"- work: 4 (e.g. w1, w2, w3)\n" +
+		"- (unset): 1 (e.g. n1)\n" +
+		"... and 2 more values\n"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	if _, err := callTool(ctx, client, "facet-memories", map[string]any{"field": ""}); err == nil {
+		t.Error("Expected error for empty field but got none")
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	valid := []string{
+		"genre = 'jazz'",
+		`genre != "rock" AND year >= 1950`,
+		"(year < 2000 OR year > 2010) and rating <= 4.5",
+		"title GLOB 'A*' AND title NOT GLOB '*z'",
+		"country IN ('US', 'UK') OR country NOT IN (\"FR\")",
+		"tags CONTAINS 'live' AND tags NOT CONTAINS 'demo'",
+		"HAS FIELD priority AND HAS NOT FIELD archived",
+		"geo.city = 'Oslo' AND scores[0] > -1.5 AND scores[#-1] = 3 AND active = true",
+	}
+	for _, filter := range valid {
+		t.Run(filter, func(t *testing.T) {
+			got, err := callTool(ctx, client, "validate-filter", map[string]any{"filter": filter})
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got != "valid" {
+				t.Errorf("Got %q, want %q", got, "valid")
+			}
+		})
+	}
+
+	invalid := []struct {
+		filter   string
+		expected string
+	}{
+		{"", "invalid: position 1: filter is empty"},
+		{"genre = ", "invalid: position 9: expected a string, number or boolean value, got end of filter"},
+		{"genre 'jazz'", "invalid: position 7: expected comparison operator after field 'genre', got string 'jazz'"},
+		{"genre == 'jazz'", "invalid: position 7: unexpected '==', use '=' for equality"},
+		{"genre = 'jazz", "invalid: position 9: unterminated string"},
+		{"(year > 1 OR year < 0", "invalid: position 22: expected ')' to close '(' at position 1, got end of filter"},
+		{"year > 1 year < 0", "invalid: position 10: expected AND, OR or end of filter, got 'year'"},
+		{"AND year > 1", "invalid: position 1: expected field name or '(', got 'AND'"},
+		{"country IN ('US' 'UK')", "invalid: position 18: expected ',' or ')' in IN list, got string 'UK'"},
+		{"title GLOB 5", "invalid: position 12: expected quoted pattern after GLOB, got '5'"},
+		{"title NOT = 'x'", "invalid: position 11: expected GLOB, IN or CONTAINS after NOT, got '='"},
+		{"HAS priority", "invalid: position 5: expected FIELD after HAS, got 'priority'"},
+		{"scores[x] = 1", "invalid: position 7: invalid array index '[x]'"},
+		{"year > 1 & year < 3", "invalid: position 10: unexpected character '&'"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := callTool(ctx, client, "validate-filter", map[string]any{"filter": tt.filter})
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAddToMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.Now = func() time.Time { return now }
+	cfg.ChunkSize = 10
+	cfg.ChunkOverlap = 2
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, args := range []map[string]any{
+		{"id": "note", "content": "parking spot is B12", "ttl_seconds": 1},
+		{"id": "fact", "content": "parking garage closes at 10pm"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("CallTool:", err)
+		}
+	}
+	if expiresAt := index.data["note"].metadata["expires_at"]; expiresAt != "2025-03-01T12:00:01Z" {
+		t.Errorf("Got expires_at %v, want 2025-03-01T12:00:01Z", expiresAt)
+	}
+
+	got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "note"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Memory ID: note\nContent: parking spot is B12\nCreated: 2025-03-01T12:00:00Z\nUpdated: 2025-03-01T12:00:00Z"; got != expected {
+		t.Errorf("Before expiry: got %q, want %q", got, expected)
+	}
+
+	now = now.Add(2 * time.Second)
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "parking"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if strings.Contains(got, "ID: note") || !strings.Contains(got, "Found 1 memories") {
+		t.Errorf("Expected the expired memory filtered out of search, got: %s", got)
+	}
+	if _, exists := index.data["note"]; exists {
+		t.Error("Expected the expired memory deleted when read")
+	}
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "chunked", "content": "abcdefghijklmnopqrstuvwxyz", "chunk": true, "ttl_seconds": 1}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	now = now.Add(time.Second)
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "chunked"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Memory with ID 'chunked' not found"; got != expected {
+		t.Errorf("After expiry: got %q, want %q", got, expected)
+	}
+	if _, exists := index.data["chunked#0"]; exists {
+		t.Error("Expected the expired chunks deleted when read")
+	}
+
+	for _, ttl := range []any{0, -5, "soon"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "bad", "content": "x", "ttl_seconds": ttl}); err == nil {
+			t.Errorf("ttl_seconds %v: expected error but got none", ttl)
+		}
+	}
+}
+
+func TestAddToMemoryDedup(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "coffee", "content": "Prefers dark roast coffee"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]any
+		expected string
+		stored   bool
+	}{
+		{
+			name:     "near-duplicate is skipped",
+			args:     map[string]any{"id": "coffee-again", "content": "dark roast coffee", "dedup": true},
+			expected: "Skipped memory with ID: coffee-again, it duplicates memory 'coffee' (score: 0.9500)",
+		},
+		{
+			name:     "threshold above the score lets it through",
+			args:     map[string]any{"id": "coffee-strict", "content": "dark roast coffee", "dedup": true, "threshold": 0.99},
+			expected: "Successfully stored memory with ID: coffee-strict",
+			stored:   true,
+		},
+		{
+			name:     "rewriting the same ID is not a duplicate of itself",
+			args:     map[string]any{"id": "coffee", "content": "Prefers dark roast coffee", "dedup": true},
+			expected: "Successfully stored memory with ID: coffee",
+			stored:   true,
+		},
+		{
+			name:     "unrelated content is stored",
+			args:     map[string]any{"id": "tea", "content": "Dislikes green tea", "dedup": true},
+			expected: "Successfully stored memory with ID: tea",
+			stored:   true,
+		},
+		{
+			name:     "without dedup duplicates are stored",
+			args:     map[string]any{"id": "coffee-plain", "content": "dark roast coffee"},
+			expected: "Successfully stored memory with ID: coffee-plain",
+			stored:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callTool(ctx, client, "add-to-memory", tt.args)
+			if err != nil {
+				t.Fatal("CallTool:", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Got %q, want %q", got, tt.expected)
+			}
+			if _, ok := index.data[tt.args["id"].(string)]; ok != tt.stored {
+				t.Errorf("Stored = %v, want %v", ok, tt.stored)
+			}
+		})
+	}
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "x", "content": "dark roast coffee", "dedup": true, "threshold": 2}); err == nil {
+		t.Error("Expected error for out-of-range threshold but got none")
+	}
+}
+
+func TestMetadataSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schema.json")
+	schema := `{
+		"type": "object",
+		"required": ["source"],
+		"properties": {"source": {"type": "string"}}
+	}`
+	if err := os.WriteFile(path, []byte(schema), 0o600); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	cfg := memory.DefaultConfig()
+	var err error
+	if cfg.MetadataSchema, err = memory.LoadMetadataSchema(path); err != nil {
+		t.Fatal("LoadMetadataSchema:", err)
+	}
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	got, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "ok", "content": "conforming", "metadata": map[string]any{"source": "chat"}})
+	if err != nil {
+		t.Fatal("Expected conforming metadata to be accepted, got:", err)
+	}
+	if expected := "Successfully stored memory with ID: ok"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	rejected := []struct {
+		name string
+		tool string
+		args map[string]any
+		want string
+	}{
+		{
+			name: "missing field",
+			tool: "add-to-memory",
+			args: map[string]any{"id": "bad", "content": "no source", "metadata": map[string]any{"topic": "x"}},
+			want: "metadata does not match the schema: at '': missing property 'source'",
+		},
+		{
+			name: "no metadata",
+			tool: "add-to-memory",
+			args: map[string]any{"id": "bad", "content": "no metadata"},
+			want: "missing property 'source'",
+		},
+		{
+			name: "wrong type",
+			tool: "add-to-memory",
+			args: map[string]any{"id": "bad", "content": "numeric source", "metadata": map[string]any{"source": 7}},
+			want: "at '/source': got number, want string",
+		},
+		{
+			name: "update removing the field",
+			tool: "update-memory",
+			args: map[string]any{"id": "ok", "metadata": map[string]any{"source": nil}},
+			want: "missing property 'source'",
+		},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := callTool(ctx, client, tt.tool, tt.args)
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Got error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+	if _, ok := index.data["bad"]; ok {
+		t.Error("Non-conforming memory was stored")
+	}
+	if index.data["ok"].metadata["source"] != "chat" {
+		t.Error("Rejected update changed the stored metadata")
+	}
+
+	got, err = callTool(ctx, client, "batch-add-memories", map[string]any{"memories": []any{
+		map[string]any{"id": "b1", "content": "first", "metadata": map[string]any{"source": "import"}},
+		map[string]any{"id": "b2", "content": "second"},
+	}})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if _, ok := index.data["b2"]; ok || index.data["b1"].data != "first" {
+		t.Errorf("Expected only the conforming batch entry stored, got %q", got)
+	}
+
+	if _, err := memory.LoadMetadataSchema(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error loading a missing schema file")
+	}
+}
+
+func TestAddToMemoryTimestamps(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := memory.DefaultConfig()
+	cfg.Now = func() time.Time { return now }
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	assertStamps := func(t *testing.T, id, created, updated string) {
+		t.Helper()
+		meta := index.data[id].metadata
+		if meta["created_at"] != created || meta["updated_at"] != updated {
+			t.Errorf("%s: created_at=%v updated_at=%v, want %s and %s", id, meta["created_at"], meta["updated_at"], created, updated)
+		}
+	}
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "note", "content": "first draft"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	assertStamps(t, "note", "2024-03-01T12:00:00Z", "2024-03-01T12:00:00Z")
+
+	now = now.Add(time.Hour)
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "note", "content": "second draft"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	assertStamps(t, "note", "2024-03-01T12:00:00Z", "2024-03-01T13:00:00Z")
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "old", "content": "imported", "created_at": "2020-01-02T03:04:05+02:00"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	assertStamps(t, "old", "2020-01-02T01:04:05Z", "2024-03-01T13:00:00Z")
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "bad", "content": "x", "created_at": "yesterday"}); err == nil {
+		t.Error("Expected error for malformed created_at but got none")
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg.AutoTimestamps = false
+		index := NewMockVectorIndex()
+		srv := createMemoryMCPServerWith(t, index, cfg)
+		defer srv.Close()
+		if err := srv.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := callTool(ctx, srv.Client(), "add-to-memory", map[string]any{"id": "plain", "content": "no clock"}); err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		if meta := index.data["plain"].metadata; meta["created_at"] != nil || meta["updated_at"] != nil {
+			t.Errorf("Expected no timestamps, got %v", meta)
+		}
+	})
+}
+
+func TestRenameMemory(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for id, content := range map[string]string{"tmp1": "Prefers window seats", "seats": "Likes the front row"} {
+		args := map[string]any{"id": id, "content": content, "metadata": map[string]any{"topic": "travel"}}
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "rename-memory", map[string]any{"old_id": "tmp1", "new_id": "seat-preference"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "Renamed memory 'tmp1' to 'seat-preference'" {
+		t.Errorf("Got %q", got)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "tmp1"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Memory with ID 'tmp1' not found") {
+		t.Errorf("Expected the old ID to be gone, got %q", got)
+	}
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "seat-preference"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Content: Prefers window seats") || !strings.Contains(got, `"topic":"travel"`) {
+		t.Errorf("Expected the new ID to hold the content and metadata, got %q", got)
+	}
+
+	if _, err := callTool(ctx, client, "rename-memory", map[string]any{"old_id": "seat-preference", "new_id": "seats"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected a refusal to overwrite, got %v", err)
+	}
+	got, err = callTool(ctx, client, "rename-memory", map[string]any{"old_id": "seat-preference", "new_id": "seats", "overwrite": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "replacing the memory that had that ID") {
+		t.Errorf("Got %q", got)
+	}
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "seats"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Content: Prefers window seats") {
+		t.Errorf("Expected the renamed memory to replace the old one, got %q", got)
+	}
+
+	for _, args := range []map[string]any{
+		{"old_id": "missing", "new_id": "other"},
+		{"old_id": "seats", "new_id": "seats"},
+		{"old_id": "seats", "new_id": " "},
+	} {
+		if _, err := callTool(ctx, client, "rename-memory", args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
+
+func TestUpdateMemoryTimestamps(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := memory.DefaultConfig()
+	cfg.Now = func() time.Time { return now }
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "note", "content": "first draft"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	now = now.Add(90 * time.Minute)
+	if _, err := callTool(ctx, client, "update-memory", map[string]any{"id": "note", "content": "second draft"}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	got, err := callTool(ctx, client, "get-memory", map[string]any{"id": "note"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Memory ID: note\nContent: second draft\nCreated: 2024-03-01T12:00:00Z\nUpdated: 2024-03-01T13:30:00Z"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "note", "format": "json"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	var results []memory.MemoryResult
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", got, err)
+	}
+	if len(results) != 1 || results[0].Created != "2024-03-01T12:00:00Z" || results[0].Updated != "2024-03-01T13:30:00Z" {
+		t.Errorf("Got %+v, want created_at 2024-03-01T12:00:00Z and updated_at 2024-03-01T13:30:00Z", results)
+	}
+}
+
+func TestBackfillTimestamps(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.Now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	seed := []vector.UpsertData{
+		{Id: "legacy-1", Data: "old note"},
+		{Id: "legacy-2", Data: "older note", Metadata: map[string]any{"category": "misc"}},
+		{Id: "stamped", Data: "new note", Metadata: map[string]any{"created_at": "2023-05-05T00:00:00Z"}},
+	}
+	for _, d := range seed {
+		if err := index.UpsertData(ctx, d); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "backfill-timestamps", map[string]any{"dry_run": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Dry run: would set created_at to 2024-03-01T12:00:00Z on 2 of 3 memories"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if _, ok := index.data["legacy-1"].metadata["created_at"]; ok {
+		t.Error("Dry run wrote a timestamp")
+	}
+
+	got, err = callTool(ctx, client, "backfill-timestamps", map[string]any{"created_at": "2022-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Set created_at to 2022-01-01T00:00:00Z on 2 of 3 memories"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	want := map[string]string{
+		"legacy-1": "2022-01-01T00:00:00Z",
+		"legacy-2": "2022-01-01T00:00:00Z",
+		"stamped":  "2023-05-05T00:00:00Z",
+	}
+	for id, createdAt := range want {
+		if got := index.data[id].metadata["created_at"]; got != createdAt {
+			t.Errorf("%s: created_at = %v, want %s", id, got, createdAt)
+		}
+	}
+	if record := index.data["legacy-2"]; record.data != "older note" || record.metadata["category"] != "misc" {
+		t.Errorf("Backfill lost existing data: %+v", record)
+	}
+
+	got, err = callTool(ctx, client, "backfill-timestamps", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Set created_at to 2024-03-01T12:00:00Z on 0 of 3 memories"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+}
+
+func TestExportMemoriesJSON(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServerWith(t, NewMockVectorIndex(), memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	got, err := callTool(ctx, client, "export-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "[]" {
+		t.Errorf("Expected an empty store to export as [], got: %s", got)
+	}
+
+	type record struct {
+		ID       string         `json:"id"`
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	want := []record{
+		{ID: "alpha", Content: "first memory", Metadata: map[string]any{"topic": "go"}},
+		{ID: "beta", Content: "second memory", Metadata: map[string]any{"topic": "rust", "stars": float64(4)}},
+		{ID: "gamma", Content: "third memory", Metadata: map[string]any{"tags": []any{"a", "b"}}},
+	}
+	for _, r := range want {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": r.ID, "content": r.Content, "metadata": r.Metadata}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "elsewhere", "content": "other namespace", "namespace": "work"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	export := func(args map[string]any) []record {
+		t.Helper()
+		got, err := callTool(ctx, client, "export-memories", args)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		var records []record
+		if err := json.Unmarshal([]byte(got), &records); err != nil {
+			t.Fatalf("export is not a JSON array: %v: %s", err, got)
+		}
+		return records
+	}
+
+	records := export(nil)
+	if len(records) != len(want) {
+		t.Fatalf("Got %d records, want %d: %+v", len(records), len(want), records)
+	}
+	for i, r := range records {
+		if r.ID != want[i].ID || r.Content != want[i].Content {
+			t.Errorf("Record %d round-tripped as %+v, want %+v", i, r, want[i])
+		}
+		for key, value := range want[i].Metadata {
+			if !reflect.DeepEqual(r.Metadata[key], value) {
+				t.Errorf("Record %s metadata %s = %v, want %v", r.ID, key, r.Metadata[key], value)
+			}
+		}
+	}
+
+	if records := export(map[string]any{"max": 2}); len(records) != 2 || records[1].ID != "beta" {
+		t.Errorf("Expected max to cap the export at 2 records, got: %+v", records)
+	}
+
+	if records := export(map[string]any{"namespace": "work"}); len(records) != 1 || records[0].ID != "elsewhere" {
+		t.Errorf("Expected only the work namespace, got: %+v", records)
+	}
+
+	for _, maxRecords := range []any{0, 1.5, "all"} {
+		if _, err := callTool(ctx, client, "export-memories", map[string]any{"max": maxRecords}); err == nil {
+			t.Errorf("max %v: expected error but got none", maxRecords)
+		}
+	}
+}
+
+func TestImportMemories(t *testing.T) {
+	ctx := context.Background()
+	srv := createAdminMemoryMCPServerWith(t, NewMockVectorIndex(), memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, args := range []map[string]any{
+		{"id": "alpha", "content": "first memory", "metadata": map[string]any{"topic": "go"}},
+		{"id": "beta", "content": "second memory", "ttl_seconds": 3600},
+		{"id": "gamma", "content": "third memory", "created_at": "2024-01-01T00:00:00Z"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", args); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	dump, err := callTool(ctx, client, "export-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	if _, err := callTool(ctx, client, "purge-all-memories", map[string]any{"confirm": memory.PurgeConfirmation}); err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got, _ := callTool(ctx, client, "export-memories", nil); got != "[]" {
+		t.Fatalf("Expected an empty store after purging, got: %s", got)
+	}
+
+	// Importing twice overwrites rather than failing or duplicating.
+	for range 2 {
+		got, err := callTool(ctx, client, "import-memories", map[string]any{"memories": dump})
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		if expected := "Inserted 3 of 3 memories, skipped 0"; got != expected {
+			t.Errorf("Got %q, want %q", got, expected)
+		}
+	}
+
+	restored, err := callTool(ctx, client, "export-memories", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if restored != dump {
+		t.Errorf("Expected the store to be restored exactly\ngot:  %s\nwant: %s", restored, dump)
+	}
+
+	got, err := callTool(ctx, client, "import-memories", map[string]any{"memories": []any{
+		map[string]any{"id": "delta", "content": "fourth memory"},
+		map[string]any{"content": "no id"},
+		map[string]any{"id": "epsilon", "content": "bad metadata", "metadata": "not an object"},
+		"not an object",
+	}})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	expected := "Inserted 1 of 4 memories, skipped 3\nSkipped (3):\n- #1: missing id\n- epsilon: metadata must be a JSON object, got string\n- #3: entry is not an object"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	for _, payload := range []any{"not json", `{"id": "x"}`, nil} {
+		if _, err := callTool(ctx, client, "import-memories", map[string]any{"memories": payload}); err == nil {
+			t.Errorf("payload %v: expected error but got none", payload)
+		}
+	}
+}
+
+func TestExportMemoriesNDJSON(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+
+	s := server.NewMCPServer("export-test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(memory.NewService(index, memory.DefaultConfig()).Tools()...)
+	client := startStdioClient(t, s)
+
+	// Enough records to span several Range pages.
+	want := make(map[string]string)
+	for i := range 250 {
+		id := fmt.Sprintf("mem-%03d", i)
+		want[id] = fmt.Sprintf("memory number %d", i)
+		index.UpsertData(ctx, vector.UpsertData{Id: id, Data: want[id], Metadata: map[string]any{"n": float64(i)}})
+	}
+
+	type record struct {
+		ID       string         `json:"id"`
+		Content  string         `json:"content"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	checkLines := func(t *testing.T, ndjson string) map[string]record {
+		t.Helper()
+		got := make(map[string]record)
+		for _, line := range strings.Split(strings.TrimSuffix(ndjson, "\n"), "\n") {
+			var r record
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("line is not standalone JSON: %q: %v", line, err)
+			}
+			got[r.ID] = r
+		}
+		return got
+	}
+	checkRoundTrip := func(t *testing.T, got map[string]record) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("Got %d records, want %d", len(got), len(want))
+		}
+		for id, content := range want {
+			if got[id].Content != content || got[id].Metadata["n"] == nil {
+				t.Errorf("%s round-tripped as %+v", id, got[id])
+			}
+		}
+	}
+
+	t.Run("streamed", func(t *testing.T) {
+		messages := make(chan string, 10)
+		client.OnNotification(func(n mcp.JSONRPCNotification) {
+			if n.Method == "notifications/progress" && n.Params.AdditionalFields["progressToken"] == "export-1" {
+				messages <- n.Params.AdditionalFields["message"].(string)
+			}
+		})
+
+		var req mcp.CallToolRequest
+		req.Params.Name = "export-memories"
+		req.Params.Arguments = map[string]any{"format": "ndjson"}
+		req.Params.Meta = &mcp.Meta{ProgressToken: "export-1"}
+
+		result, err := client.CallTool(ctx, req)
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		summary, err := resultToString(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "Exported 250 memories as NDJSON in 3 progress notifications"; summary != expected {
+			t.Fatalf("Got %q, want %q", summary, expected)
+		}
+
+		got := make(map[string]record)
+		for range 3 {
+			select {
+			case message := <-messages:
+				for id, r := range checkLines(t, message) {
+					got[id] = r
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for streamed records")
+			}
+		}
+		checkRoundTrip(t, got)
+	})
+
+	t.Run("buffered without progress token", func(t *testing.T) {
+		got, err := callTool(ctx, client, "export-memories", map[string]any{"format": "ndjson"})
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		checkRoundTrip(t, checkLines(t, got))
+	})
+
+	t.Run("capped by max", func(t *testing.T) {
+		got, err := callTool(ctx, client, "export-memories", map[string]any{"format": "ndjson", "max": 120})
+		if err != nil {
+			t.Fatal("CallTool:", err)
+		}
+		if lines := checkLines(t, got); len(lines) != 120 {
+			t.Errorf("Got %d records, want 120", len(lines))
+		}
+	})
+
+	if _, err := callTool(ctx, client, "export-memories", map[string]any{"format": "csv"}); err == nil {
+		t.Error("Expected error for unknown format but got none")
+	}
+}
+
+func TestAddToMemoryChunking(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	cfg := memory.DefaultConfig()
+	cfg.ChunkSize = 40
+	cfg.ChunkOverlap = 10
+	srv := createMemoryMCPServerWith(t, index, cfg)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	content := "Chapter one covers sourdough starters. Chapter two explains proofing baskets. Chapter three is about scoring patterns and oven spring."
+
+	got, err := callTool(ctx, client, "add-to-memory", map[string]any{
+		"id":       "bread",
+		"content":  content,
+		"metadata": map[string]any{"topic": "baking"},
+		"chunk":    true,
+	})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.HasPrefix(got, "Successfully stored memory with ID: bread in ") {
+		t.Fatalf("Unexpected add response: %s", got)
+	}
+
+	if _, exists := index.data["bread"]; exists {
+		t.Error("Expected no unchunked record under the parent ID")
+	}
+	first, exists := index.data["bread#0"]
+	if !exists {
+		t.Fatal("Expected first chunk stored as bread#0")
+	}
+	if first.metadata["parent_id"] != "bread" {
+		t.Errorf("Expected chunk metadata to link to parent, got %v", first.metadata)
+	}
+	if first.metadata["topic"] != "baking" {
+		t.Errorf("Expected chunk to carry the memory's metadata, got %v", first.metadata)
+	}
+	for id, record := range index.data {
+		if n := len([]rune(record.data)); n > cfg.ChunkSize {
+			t.Errorf("Chunk %s has %d runes, want at most %d", id, n, cfg.ChunkSize)
+		}
+	}
+
+	got, err = callTool(ctx, client, "search-memory", map[string]any{"query": "oven spring"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "Found 1 memories:") || !strings.Contains(got, "ID: bread#") {
+		t.Errorf("Expected a single chunk hit, got: %s", got)
+	}
+
+	got, err = callTool(ctx, client, "get-memory", map[string]any{"id": "bread"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "reassembled from") {
+		t.Errorf("Expected reassembled memory, got: %s", got)
+	}
+	if !strings.HasSuffix(got, "Content: "+content) {
+		t.Errorf("Reassembled content differs from original: %s", got)
+	}
+}
+
+func TestAddToMemoryChunkShortContent(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(ctx, srv.Client(), "add-to-memory", map[string]any{"id": "short", "content": "fits in one chunk", "chunk": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "Successfully stored memory with ID: short" {
+		t.Errorf("Got %q, want plain store for short content", got)
+	}
+	if _, exists := index.data["short"]; !exists {
+		t.Error("Expected short content stored under its own ID")
+	}
+}
+
+func TestPurgeAllMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createAdminMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, id := range []string{"one", "two", "three"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "disposable"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "kept", "content": "disposable", "namespace": "scratch"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"confirm": "yes"},
+		{"confirm": "YES-DELETE-EVERYTHING"},
+	} {
+		if _, err := callTool(ctx, client, "purge-all-memories", args); err == nil {
+			t.Errorf("Expected purge to be refused for %v", args)
+		}
+	}
+	if len(index.data) != 3 {
+		t.Fatalf("Refused purge removed memories: %d left", len(index.data))
+	}
+
+	got, err := callTool(ctx, client, "purge-all-memories", map[string]any{"confirm": memory.PurgeConfirmation})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "Purged 3 memories" {
+		t.Errorf("Got %q, want %q", got, "Purged 3 memories")
+	}
+	if len(index.data) != 0 {
+		t.Errorf("Expected empty index after purge, %d left", len(index.data))
+	}
+	if got, _ := callTool(ctx, client, "get-memory", map[string]any{"id": "kept", "namespace": "scratch"}); !strings.Contains(got, "disposable") {
+		t.Errorf("Expected purging the default namespace to leave others untouched, got: %s", got)
+	}
+
+	if _, err := callTool(ctx, client, "purge-all-memories", map[string]any{"namespace": "scratch"}); err == nil {
+		t.Error("Expected a namespaced purge to be refused without confirmation")
+	}
+	got, err = callTool(ctx, client, "purge-all-memories", map[string]any{"confirm": memory.PurgeConfirmation, "namespace": "scratch"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Purged 1 memories from namespace 'scratch'"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if n := len(index.namespaces["scratch"].data); n != 0 {
+		t.Errorf("Expected the scratch namespace to be empty, %d left", n)
+	}
+}
+
+func TestPurgeAllMemoriesNeedsAdmin(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	service := memory.NewService(index, memory.DefaultConfig())
+
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddTools(service.Tools()...)
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	member := mcptest.NewUnstartedServer(t)
+	for _, tool := range service.Tools() {
+		member.AddTools(withGrant(auth.Grant{}, tool))
+	}
+	if err := member.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer member.Close()
+
+	if _, err := callTool(ctx, srv.Client(), "add-to-memory", map[string]any{"id": "one", "content": "kept"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	args := map[string]any{"confirm": memory.PurgeConfirmation}
+	if _, err := callTool(ctx, srv.Client(), "purge-all-memories", args); err == nil {
+		t.Error("Expected an unauthenticated purge to be refused")
+	}
+	if _, err := callTool(ctx, member.Client(), "purge-all-memories", args); err == nil {
+		t.Error("Expected a purge without the admin role to be refused")
+	}
+	if len(index.data) != 1 {
+		t.Errorf("Refused purge removed memories: %d left", len(index.data))
+	}
+
+	// Previewing deletes nothing, so it needs no admin.
+	if _, err := callTool(ctx, srv.Client(), "purge-all-memories", map[string]any{"dry_run": true}); err != nil {
+		t.Error("CallTool:", err)
+	}
+}
+
+func TestClearAllMemories(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createAdminMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for _, id := range []string{"one", "two"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "disposable"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": "kept", "content": "disposable", "namespace": "scratch"}); err != nil {
+		t.Fatal("Setup failed:", err)
+	}
+
+	for _, args := range []map[string]any{
+		{},
+		{"confirm": ""},
+		{"confirm": "yes"},
+		{"confirm": "YES-DELETE-EVERYTHING"},
+	} {
+		_, err := callTool(ctx, client, "clear-all-memories", args)
+		if err == nil || !strings.Contains(err.Error(), memory.PurgeConfirmation) {
+			t.Errorf("Expected clear to be refused for %v with the token it needs, got %v", args, err)
+		}
+	}
+	if index.resets != 0 || len(index.data) != 2 {
+		t.Fatalf("Refused clear reset the index: %d resets, %d left", index.resets, len(index.data))
+	}
+
+	got, err := callTool(ctx, client, "clear-all-memories", map[string]any{"confirm": memory.PurgeConfirmation})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "Cleared 2 memories" {
+		t.Errorf("Got %q, want %q", got, "Cleared 2 memories")
+	}
+	if index.resets != 1 || len(index.data) != 0 {
+		t.Errorf("Expected one reset emptying the index, got %d resets, %d left", index.resets, len(index.data))
+	}
+	scratch := index.namespaces["scratch"]
+	if scratch.resets != 0 || len(scratch.data) != 1 {
+		t.Errorf("Expected clearing the default namespace to leave others untouched, got %d resets, %d left", scratch.resets, len(scratch.data))
+	}
+
+	got, err = callTool(ctx, client, "clear-all-memories", map[string]any{"confirm": memory.PurgeConfirmation, "namespace": "scratch"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Cleared 1 memories from namespace 'scratch'"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+	if scratch.resets != 1 || len(scratch.data) != 0 {
+		t.Errorf("Expected one reset emptying the scratch namespace, got %d resets, %d left", scratch.resets, len(scratch.data))
+	}
+}
+
+func TestClearAllMemoriesNeedsAdmin(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := callTool(ctx, srv.Client(), "clear-all-memories", map[string]any{"confirm": memory.PurgeConfirmation}); err == nil {
+		t.Error("Expected an unauthenticated clear to be refused")
+	}
+	if index.resets != 0 {
+		t.Errorf("Refused clear reset the index %d times", index.resets)
+	}
+}
+
+func TestPurgeAllMemoriesDryRun(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	srv := createMemoryMCPServerWith(t, index, memory.DefaultConfig())
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for i := range 25 {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": fmt.Sprintf("m%02d", i), "content": "disposable"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if _, err := callTool(ctx, client, "add-to-memory", map[string]any{"id": id, "content": "scratch", "namespace": "scratch"}); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err := callTool(ctx, client, "purge-all-memories", map[string]any{"dry_run": true})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	var first []string
+	for i := range 20 {
+		first = append(first, fmt.Sprintf("m%02d", i))
+	}
+	if expected := "Dry run: would purge 25 memories\nFirst 20 IDs: " + strings.Join(first, ", "); got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	got, err = callTool(ctx, client, "purge-all-memories", map[string]any{"dry_run": true, "namespace": "scratch", "confirm": memory.PurgeConfirmation})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Dry run: would purge 2 memories from namespace 'scratch'\nIDs: a, b"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+
+	if n := len(index.data); n != 25 {
+		t.Errorf("Dry run deleted memories: %d left, want 25", n)
+	}
+	if n := len(index.namespaces["scratch"].data); n != 2 {
+		t.Errorf("Dry run deleted namespaced memories: %d left, want 2", n)
+	}
+
+	got, err = callTool(ctx, client, "purge-all-memories", map[string]any{"dry_run": true, "namespace": "empty"})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if expected := "Dry run: would purge 0 memories from namespace 'empty'"; got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+}
+
+// startStdioClient serves s over in-memory pipes and returns a started,
+// initialized client. Unlike mcptest's client it delivers server
+// notifications to OnNotification handlers.
+func startStdioClient(t *testing.T, s *server.MCPServer) *client.Client {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	go server.NewStdioServer(s).Listen(ctx, serverReader, serverWriter)
+
+	c := client.NewClient(transport.NewIO(clientReader, clientWriter, io.NopCloser(strings.NewReader(""))))
+	t.Cleanup(func() {
+		cancel()
+		c.Close()
+		serverWriter.Close()
+		serverReader.Close()
+	})
+
+	if err := c.Start(ctx); err != nil {
+		t.Fatal("Start:", err)
+	}
+	var initReq mcp.InitializeRequest
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		t.Fatal("Initialize:", err)
+	}
+	return c
 }
 
 func resultToString(result *mcp.CallToolResult) (string, error) {
@@ -497,4 +4148,68 @@ func resultToString(result *mcp.CallToolResult) (string, error) {
 	}
 
 	return b.String(), nil
-}
\ No newline at end of file
+}
+
+func callTool(ctx context.Context, c *client.Client, name string, args map[string]any) (string, error) {
+	var req mcp.CallToolRequest
+	req.Params.Name = name
+	req.Params.Arguments = args
+
+	result, err := c.CallTool(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return resultToString(result)
+}
+
+var timestampLines = regexp.MustCompile(`\n(Created|Updated): \S+`)
+
+// withoutTimestamps drops the Created and Updated lines from get-memory
+// output, for tests that run against the wall clock.
+func withoutTimestamps(text string) string {
+	return timestampLines.ReplaceAllString(text, "")
+}
+
+func TestTagUsageStats(t *testing.T) {
+	ctx := context.Background()
+	srv := createMemoryMCPServer(t)
+	defer srv.Close()
+
+	err := srv.Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	got, err := callTool(ctx, client, "tag-usage-stats", map[string]any{})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if got != "No memories stored" {
+		t.Errorf("Got %q for an empty index", got)
+	}
+
+	for _, m := range []map[string]any{
+		{"id": "a", "content": "alpha notes", "metadata": map[string]any{"tags": []any{"work", "ml"}}},
+		{"id": "b", "content": "beta", "metadata": map[string]any{"tags": []any{"work"}}},
+		{"id": "c", "content": "déjà", "metadata": map[string]any{"tags": []any{"ml", "ml"}}},
+		{"id": "d", "content": "gamma!"},
+	} {
+		if _, err := callTool(ctx, client, "add-to-memory", m); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+
+	got, err = callTool(ctx, client, "tag-usage-stats", map[string]any{})
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	expected := "Tag usage across 4 memories:\n" +
+		"- ml: 2 memories, 17 bytes\n" +
+		"- work: 2 memories, 15 bytes\n" +
+		"- (untagged): 1 memories, 6 bytes\n"
+	if got != expected {
+		t.Errorf("Got %q, want %q", got, expected)
+	}
+}
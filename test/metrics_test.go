@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/metrics"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	ctx := context.Background()
+	toolMetrics := metrics.New()
+
+	s := server.NewMCPServer("metrics-test", "1.0.0",
+		server.WithToolCapabilities(true),
+		server.WithToolHandlerMiddleware(toolMetrics.Middleware()),
+	)
+	s.AddTools(serverinfo.CapabilitiesTool())
+
+	// The SSE server and the metrics share one mux, as in the cmd programs.
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	sseServer := server.NewSSEServer(s,
+		server.WithBaseURL(ts.URL),
+		server.WithSSEEndpoint("/mcp/sse"),
+		server.WithMessageEndpoint("/mcp/message"),
+	)
+	mux.Handle("/", sseServer)
+	toolMetrics.Register(mux, metrics.DefaultPath)
+
+	scrape := func() string {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/metrics")
+		if err != nil {
+			t.Fatal("GET /metrics:", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /metrics: status %d: %s", resp.StatusCode, body)
+		}
+		return string(body)
+	}
+
+	if got := scrape(); strings.Contains(got, "mcp_tool_calls_total{") {
+		t.Errorf("Expected no tool calls counted yet, got:\n%s", got)
+	}
+
+	c, err := client.NewSSEMCPClient(ts.URL + "/mcp/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal("Start:", err)
+	}
+	var initReq mcp.InitializeRequest
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		t.Fatal("Initialize:", err)
+	}
+
+	for range 2 {
+		if _, err := callTool(ctx, c, "capabilities", nil); err != nil {
+			t.Fatal("CallTool:", err)
+		}
+	}
+
+	got := scrape()
+	for _, want := range []string{
+		`mcp_tool_calls_total{outcome="ok",tool="capabilities"} 2`,
+		`mcp_tool_call_duration_seconds_count{tool="capabilities"} 2`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %s in the metrics, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/MikeLuu99/go-mcp/internal/middleware"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var order []string
+	mark := func(name string) server.ToolHandlerMiddleware {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+
+	handler := middleware.Chain(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		order = append(order, "handler")
+		return mcp.NewToolResultText("ok"), nil
+	}, mark("outer"), mark("inner"))
+
+	if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if got := strings.Join(order, ","); got != "outer,inner,handler" {
+		t.Errorf("Got call order %q, want %q", got, "outer,inner,handler")
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	handler := middleware.Recover(logging.New(&out, slog.LevelInfo))(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.GetArguments()["panic"] == true {
+			var metadata map[string]any
+			_ = metadata["id"].(string)
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	var req mcp.CallToolRequest
+	req.Params.Name = "get-memory"
+	req.Params.Arguments = map[string]any{"panic": true}
+	result, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatal("Expected the panic as an error result, got error:", err)
+	}
+	if !result.IsError {
+		t.Fatalf("Expected an error result, got %+v", result)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "tool 'get-memory' failed with an internal error") {
+		t.Errorf("Unexpected error text: %q", text)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("log output is not one JSON line: %v: %q", err, out.String())
+	}
+	if line["level"] != "ERROR" || line["msg"] != "tool handler panicked" || line["tool"] != "get-memory" {
+		t.Errorf("Unexpected log line: %v", line)
+	}
+	if stack, _ := line["stack"].(string); !strings.Contains(stack, "middleware_test.go") {
+		t.Errorf("Expected a stack trace through the panicking handler, got %q", stack)
+	}
+
+	out.Reset()
+	req.Params.Arguments = nil
+	result, err = handler(context.Background(), req)
+	if err != nil || result.IsError {
+		t.Errorf("Expected a normal call to pass through, got %+v, %v", result, err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing logged for a normal call, got %q", out.String())
+	}
+}
+
+// TestServerSurvivesPanic checks that a panicking tool answers with an error
+// result and the server goes on serving later calls.
+func TestServerSurvivesPanic(t *testing.T) {
+	ctx := context.Background()
+	srv := mcptest.NewUnstartedServer(t)
+	recovery := middleware.Recover(logging.New(io.Discard, slog.LevelInfo))
+	srv.AddTools(
+		server.ServerTool{
+			Tool: mcp.NewTool("explode"),
+			Handler: middleware.Chain(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				panic("deliberate")
+			}, recovery),
+		},
+		server.ServerTool{
+			Tool: mcp.NewTool("echo"),
+			Handler: middleware.Chain(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("still here"), nil
+			}, recovery),
+		},
+	)
+	defer srv.Close()
+
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	for i := 0; i < 2; i++ {
+		_, err := callTool(ctx, client, "explode", nil)
+		if err == nil || !strings.Contains(err.Error(), "tool 'explode' failed with an internal error: deliberate") {
+			t.Errorf("Expected an error result for the panic, got %v", err)
+		}
+		got, err := callTool(ctx, client, "echo", nil)
+		if err != nil || got != "still here" {
+			t.Errorf("Expected the server to keep serving, got %q, %v", got, err)
+		}
+	}
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	var tool string
+	var elapsed time.Duration
+	handler := middleware.Timing(func(name string, d time.Duration) {
+		tool, elapsed = name, d
+	})(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		time.Sleep(20 * time.Millisecond)
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	var req mcp.CallToolRequest
+	req.Params.Name = "search-memory"
+	if _, err := handler(context.Background(), req); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if tool != "search-memory" {
+		t.Errorf("Got tool %q, want %q", tool, "search-memory")
+	}
+	if elapsed < 20*time.Millisecond {
+		t.Errorf("Got elapsed %s, want at least 20ms", elapsed)
+	}
+}
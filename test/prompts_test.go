@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/upstash/vector-go"
+)
+
+func TestMemoryQAPrompt(t *testing.T) {
+	ctx := context.Background()
+	index := NewMockVectorIndex()
+	for _, d := range []vector.UpsertData{
+		{Id: "roast", Data: "Prefers dark roast coffee"},
+		{Id: "milk", Data: "Takes coffee with oat milk"},
+		{Id: "allergy", Data: "Allergic to peanuts"},
+	} {
+		if err := index.UpsertData(ctx, d); err != nil {
+			t.Fatal("Setup failed:", err)
+		}
+	}
+	index.SetScore("milk", 0.8)
+
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddPrompts(memory.NewService(index, memory.DefaultConfig()).Prompts()...)
+	defer srv.Close()
+
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	getPrompt := func(args map[string]string) (string, error) {
+		var req mcp.GetPromptRequest
+		req.Params.Name = "memory-qa"
+		req.Params.Arguments = args
+		result, err := client.GetPrompt(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		if len(result.Messages) != 1 || result.Messages[0].Role != mcp.RoleUser {
+			t.Fatalf("Got messages %+v, want one user message", result.Messages)
+		}
+		return result.Messages[0].Content.(mcp.TextContent).Text, nil
+	}
+
+	got, err := getPrompt(map[string]string{"question": "coffee"})
+	if err != nil {
+		t.Fatal("GetPrompt:", err)
+	}
+	for _, want := range []string{
+		"1. [roast] Prefers dark roast coffee\n2. [milk] Takes coffee with oat milk\n",
+		"\nQuestion: coffee",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Prompt %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "peanuts") {
+		t.Errorf("Prompt %q embeds an unrelated memory", got)
+	}
+
+	got, err = getPrompt(map[string]string{"question": "coffee", "top_k": "1"})
+	if err != nil {
+		t.Fatal("GetPrompt:", err)
+	}
+	if strings.Contains(got, "oat milk") {
+		t.Errorf("Prompt %q embeds more memories than top_k", got)
+	}
+
+	got, err = getPrompt(map[string]string{"question": "tea"})
+	if err != nil {
+		t.Fatal("GetPrompt:", err)
+	}
+	if !strings.Contains(got, "(no stored memories are relevant to this question)") {
+		t.Errorf("Prompt %q does not say nothing matched", got)
+	}
+
+	for _, args := range []map[string]string{
+		{},
+		{"question": "  "},
+		{"question": "coffee", "top_k": "0"},
+		{"question": "coffee", "top_k": "many"},
+	} {
+		if _, err := getPrompt(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/auth"
+	"github.com/MikeLuu99/go-mcp/internal/ratelimit"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// clientContext returns the context the SSE server would build for an
+// unauthenticated request from remoteAddr carrying the given X-Client-ID
+// header, with no trusted proxies.
+func clientContext(remoteAddr, clientID string) context.Context {
+	return proxiedClientContext(nil, remoteAddr, clientID)
+}
+
+// proxiedClientContext is clientContext with trusted as the trusted proxies.
+func proxiedClientContext(trusted ratelimit.TrustedProxies, remoteAddr, clientID string) context.Context {
+	return trusted.WithClient(context.Background(), clientRequest(remoteAddr, clientID))
+}
+
+// authenticatedClientContext is clientContext for a request that passed
+// bearer auth.
+func authenticatedClientContext(t *testing.T, remoteAddr, clientID string) context.Context {
+	t.Helper()
+	r := clientRequest(remoteAddr, clientID)
+	r.Header.Set("Authorization", "Bearer s3cret")
+
+	var ctx context.Context
+	auth.Bearer("s3cret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = ratelimit.TrustedProxies(nil).WithClient(r.Context(), r)
+	})).ServeHTTP(httptest.NewRecorder(), r)
+	if ctx == nil {
+		t.Fatal("Expected the request to pass bearer auth")
+	}
+	return ctx
+}
+
+func clientRequest(remoteAddr, clientID string) *http.Request {
+	r := httptest.NewRequest("POST", "/mcp/message", nil)
+	r.RemoteAddr = remoteAddr
+	if clientID != "" {
+		r.Header.Set(ratelimit.ClientIDHeader, clientID)
+	}
+	return r
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := ratelimit.New(3, func() time.Time { return now })
+
+	calls := 0
+	handler := ratelimit.Middleware(limiter)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	call := func(ctx context.Context) *mcp.CallToolResult {
+		t.Helper()
+		result, err := handler(ctx, mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal("Expected the limit to be reported as a result, got error:", err)
+		}
+		return result
+	}
+
+	alice := clientContext("10.0.0.1:5000", "")
+	for i := 1; i <= 3; i++ {
+		if result := call(alice); result.IsError {
+			t.Fatalf("Call %d within the burst was rejected", i)
+		}
+	}
+
+	for i := 4; i <= 6; i++ {
+		result := call(alice)
+		if !result.IsError {
+			t.Fatalf("Call %d past the limit was allowed", i)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if !strings.Contains(text, "at most 3 tool calls per minute") {
+			t.Errorf("Expected the limit explained, got %q", text)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Got %d handler calls, want 3", calls)
+	}
+
+	// Another port on the same host is the same client; another host or
+	// client ID has its own bucket.
+	if result := call(clientContext("10.0.0.1:6000", "")); !result.IsError {
+		t.Error("Expected the limit to apply per IP address, not per connection")
+	}
+	if result := call(clientContext("10.0.0.2:5000", "")); result.IsError {
+		t.Error("Expected a different IP address to have its own allowance")
+	}
+	if result := call(authenticatedClientContext(t, "10.0.0.1:5000", "agent-7")); result.IsError {
+		t.Error("Expected an authenticated client ID to have its own allowance")
+	}
+
+	// One token refills every 20 seconds at 3 a minute.
+	now = now.Add(20 * time.Second)
+	if result := call(alice); result.IsError {
+		t.Error("Expected a call to be allowed after a token refilled")
+	}
+	if result := call(alice); !result.IsError {
+		t.Error("Expected only one token to have refilled")
+	}
+}
+
+func TestRateLimitClientID(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := ratelimit.New(2, func() time.Time { return now })
+	handler := ratelimit.Middleware(limiter)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	allowed := func(ctx context.Context) bool {
+		t.Helper()
+		result, err := handler(ctx, mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !result.IsError
+	}
+
+	// An unauthenticated client sending a new header with every call is
+	// still limited by its IP address.
+	for i := 1; i <= 4; i++ {
+		ok := allowed(clientContext("10.0.0.1:5000", fmt.Sprintf("agent-%d", i)))
+		if want := i <= 2; ok != want {
+			t.Fatalf("Call %d with a rotated client ID: allowed %v, want %v", i, ok, want)
+		}
+	}
+
+	trusted, err := ratelimit.ParseTrustedProxies(" 10.0.1.0/24, 10.0.2.7 ,")
+	if err != nil {
+		t.Fatal("ParseTrustedProxies:", err)
+	}
+	if _, err := ratelimit.ParseTrustedProxies("proxy.local"); err == nil {
+		t.Error("Expected a hostname to be rejected")
+	}
+
+	// Behind a trusted proxy, each client ID has its own allowance.
+	for _, proxy := range []string{"10.0.1.9:443", "10.0.2.7:443"} {
+		for _, id := range []string{"agent-a", "agent-b"} {
+			if !allowed(proxiedClientContext(trusted, proxy, id)) {
+				t.Errorf("Expected %s via trusted proxy %s to have its own allowance", id, proxy)
+			}
+		}
+	}
+	if allowed(proxiedClientContext(trusted, "10.0.0.1:5000", "agent-c")) {
+		t.Error("Expected the header to be ignored from an untrusted address")
+	}
+
+	// A client ID does not share a bucket with the IP address it names.
+	if !allowed(authenticatedClientContext(t, "10.0.3.1:5000", "10.0.0.1")) {
+		t.Error("Expected a client ID naming a limited IP address to have its own allowance")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// fakeRegistry records the resources SyncResources registers.
+type fakeRegistry struct {
+	uris map[string]bool
+}
+
+func (r *fakeRegistry) AddResources(resources ...server.ServerResource) {
+	for _, res := range resources {
+		r.uris[res.Resource.URI] = true
+	}
+}
+
+func (r *fakeRegistry) RemoveResource(uri string) {
+	delete(r.uris, uri)
+}
+
+func (r *fakeRegistry) sorted() []string {
+	var uris []string
+	for uri := range r.uris {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+	return uris
+}
+
+func TestMemoryResources(t *testing.T) {
+	ctx := context.Background()
+	cfg := memory.DefaultConfig()
+	cfg.ChunkSize = 10
+	cfg.ChunkOverlap = 2
+	index := NewMockVectorIndex()
+	service := memory.NewService(index, cfg)
+	add := findHandler(t, service.Tools(), "add-to-memory")
+	remember := func(args map[string]any) {
+		t.Helper()
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		if _, err := add(ctx, req); err != nil {
+			t.Fatal("add-to-memory:", err)
+		}
+	}
+
+	remember(map[string]any{"id": "pet", "content": "I have a cat"})
+	remember(map[string]any{"id": "long", "content": "abcdefghijklmnopqrstuvwxyz", "chunk": true})
+	remember(map[string]any{"id": "two words", "content": "spaces in the ID"})
+
+	resources, err := service.Resources(ctx)
+	if err != nil {
+		t.Fatal("Resources:", err)
+	}
+
+	srv := mcptest.NewUnstartedServer(t)
+	srv.AddResources(resources...)
+	srv.AddResourceTemplate(service.ResourceTemplate())
+	defer srv.Close()
+
+	if err := srv.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+
+	listed, err := client.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		t.Fatal("ListResources:", err)
+	}
+	var uris []string
+	for _, r := range listed.Resources {
+		uris = append(uris, r.URI)
+	}
+	if want := []string{"memory://long", "memory://pet", "memory://two%20words"}; !slices.Equal(uris, want) {
+		t.Errorf("Listed %v, want %v", uris, want)
+	}
+
+	read := func(uri string) (string, error) {
+		var req mcp.ReadResourceRequest
+		req.Params.URI = uri
+		result, err := client.ReadResource(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		if len(result.Contents) != 1 {
+			t.Fatalf("%s: got %d contents, want 1", uri, len(result.Contents))
+		}
+		text, ok := result.Contents[0].(mcp.TextResourceContents)
+		if !ok {
+			t.Fatalf("%s: got %T, want text contents", uri, result.Contents[0])
+		}
+		return text.Text, nil
+	}
+
+	for uri, want := range map[string]string{
+		"memory://pet":         "I have a cat",
+		"memory://long":        "abcdefghijklmnopqrstuvwxyz",
+		"memory://two%20words": "spaces in the ID",
+	} {
+		got, err := read(uri)
+		if err != nil {
+			t.Errorf("ReadResource %s: %v", uri, err)
+		} else if got != want {
+			t.Errorf("ReadResource %s: got %q, want %q", uri, got, want)
+		}
+	}
+
+	// The template reads memories stored after the list was registered.
+	remember(map[string]any{"id": "later", "content": "added after start"})
+	if got, err := read("memory://later"); err != nil || got != "added after start" {
+		t.Errorf("ReadResource memory://later: got %q, %v", got, err)
+	}
+	if _, err := read("memory://missing"); err == nil {
+		t.Error("Expected an error reading a missing memory")
+	}
+
+	t.Run("sync", func(t *testing.T) {
+		registry := &fakeRegistry{uris: map[string]bool{}}
+		if err := service.SyncResources(ctx, registry); err != nil {
+			t.Fatal("SyncResources:", err)
+		}
+		if want := []string{"memory://later", "memory://long", "memory://pet", "memory://two%20words"}; !slices.Equal(registry.sorted(), want) {
+			t.Errorf("Registered %v, want %v", registry.sorted(), want)
+		}
+
+		if _, err := index.Delete(ctx, "pet"); err != nil {
+			t.Fatal("Delete:", err)
+		}
+
+		if err := service.SyncResources(ctx, registry); err != nil {
+			t.Fatal("SyncResources:", err)
+		}
+		if want := []string{"memory://later", "memory://long", "memory://two%20words"}; !slices.Equal(registry.sorted(), want) {
+			t.Errorf("After delete, registered %v, want %v", registry.sorted(), want)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+)
+
+// sequence returns a deterministic jitter source cycling through values.
+func sequence(values ...float64) func() float64 {
+	i := 0
+	return func() float64 {
+		v := values[i%len(values)]
+		i++
+		return v
+	}
+}
+
+func TestBackoffNoJitter(t *testing.T) {
+	b := retry.Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		if got := b.Delay(i+1, 0); got != w*time.Millisecond {
+			t.Errorf("attempt %d: got %v, want %v", i+1, got, w*time.Millisecond)
+		}
+	}
+}
+
+func TestBackoffFullJitter(t *testing.T) {
+	b := retry.Backoff{
+		Base:   100 * time.Millisecond,
+		Max:    time.Second,
+		Jitter: retry.FullJitter,
+		Rand:   sequence(0, 0.5, 0.999),
+	}
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		ceiling := retry.Backoff{Base: b.Base, Max: b.Max}.Delay(attempt, 0)
+		got := b.Delay(attempt, 0)
+		if got < 0 || got >= ceiling {
+			t.Errorf("attempt %d: got %v, want within [0, %v)", attempt, got, ceiling)
+		}
+	}
+
+	b.Rand = sequence(0.5)
+	if got := b.Delay(3, 0); got != 200*time.Millisecond {
+		t.Errorf("half jitter on 400ms: got %v, want 200ms", got)
+	}
+}
+
+func TestBackoffDecorrelatedJitter(t *testing.T) {
+	b := retry.Backoff{
+		Base:   100 * time.Millisecond,
+		Max:    2 * time.Second,
+		Jitter: retry.DecorrelatedJitter,
+		Rand:   sequence(0.1, 0.9, 0.5, 0.999, 0),
+	}
+
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 10; attempt++ {
+		floor := b.Base
+		ceiling := 3 * max(prev, b.Base)
+		if ceiling > b.Max {
+			ceiling = b.Max
+		}
+
+		got := b.Delay(attempt, prev)
+		if got < floor || got > ceiling {
+			t.Errorf("attempt %d: got %v, want within [%v, %v]", attempt, got, floor, ceiling)
+		}
+		prev = got
+	}
+}
+
+func TestParseJitter(t *testing.T) {
+	tests := map[string]retry.Jitter{
+		"":             retry.NoJitter,
+		"none":         retry.NoJitter,
+		"full":         retry.FullJitter,
+		"decorrelated": retry.DecorrelatedJitter,
+	}
+	for in, want := range tests {
+		got, err := retry.ParseJitter(in)
+		if err != nil || got != want {
+			t.Errorf("ParseJitter(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	if _, err := retry.ParseJitter("sometimes"); err == nil {
+		t.Error("Expected error for unknown jitter mode")
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "server error", err: fmt.Errorf("query: %w", &retry.StatusError{Code: 502}), want: true},
+		{name: "client error", err: &retry.StatusError{Code: 404}, want: false},
+		{name: "network timeout", err: &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "connection dropped", err: io.ErrUnexpectedEOF, want: true},
+		{name: "request cancelled", err: context.Canceled, want: false},
+		{name: "validation", err: errors.New("argument 'id' is missing"), want: false},
+	}
+	for _, tt := range tests {
+		if got := retry.Transient(tt.err); got != tt.want {
+			t.Errorf("%s: Transient(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	var slept []time.Duration
+	policy := retry.Policy{
+		Attempts: 4,
+		Backoff:  retry.Backoff{Base: 100 * time.Millisecond},
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		},
+	}
+
+	calls := 0
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return &retry.StatusError{Code: 503}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Got %d calls and error %v, want success on the third call", calls, err)
+	}
+	if want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}; !reflect.DeepEqual(slept, want) {
+		t.Errorf("Slept %v, want %v", slept, want)
+	}
+
+	// A cancelled context stops the retries with the last error.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	policy.Sleep = nil
+	err = policy.Do(ctx, func() error {
+		calls++
+		return &retry.StatusError{Code: 503}
+	})
+	if calls != 1 || !retry.Transient(err) {
+		t.Errorf("Got %d calls and error %v, want one call returning the transient error", calls, err)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &retry.Transport{}}
+	_, err := client.Get(srv.URL)
+	var statusErr *retry.StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusServiceUnavailable || !retry.Transient(err) {
+		t.Errorf("Expected a transient status error for a 503, got %v", err)
+	}
+
+	status = http.StatusBadRequest
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal("Expected a 400 to pass through, got", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Got status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestRetryPolicyFromEnv(t *testing.T) {
+	t.Setenv("RETRY_ATTEMPTS", "")
+	t.Setenv("RETRY_BASE_DELAY", "")
+	t.Setenv("RETRY_MAX_DELAY", "")
+	t.Setenv("RETRY_JITTER", "")
+	policy, err := retry.PolicyFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := retry.Backoff{Base: 100 * time.Millisecond, Max: 2 * time.Second, Jitter: retry.FullJitter}
+	if policy.Attempts != 3 || !reflect.DeepEqual(policy.Backoff, want) {
+		t.Errorf("Got defaults %+v", policy)
+	}
+
+	t.Setenv("RETRY_ATTEMPTS", "0")
+	if _, err := retry.PolicyFromEnv(); err == nil {
+		t.Error("Expected error for RETRY_ATTEMPTS 0")
+	}
+	t.Setenv("RETRY_ATTEMPTS", "5")
+	t.Setenv("RETRY_JITTER", "sometimes")
+	if _, err := retry.PolicyFromEnv(); err == nil {
+		t.Error("Expected error for an unknown RETRY_JITTER")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/memory"
+	"github.com/MikeLuu99/go-mcp/internal/papers"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestCapabilitiesTool(t *testing.T) {
+	ctx := context.Background()
+
+	s := server.NewMCPServer("capabilities-test", "2.3.4",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
+	)
+	s.AddTools(serverinfo.CapabilitiesTool())
+
+	c, err := client.NewInProcessClient(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var initReq mcp.InitializeRequest
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		t.Fatal("Initialize:", err)
+	}
+
+	var req mcp.CallToolRequest
+	req.Params.Name = "capabilities"
+
+	result, err := c.CallTool(ctx, req)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+
+	got, err := resultToString(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var caps serverinfo.Capabilities
+	if err := json.Unmarshal([]byte(got), &caps); err != nil {
+		t.Fatalf("capabilities output is not valid JSON: %v\n%s", err, got)
+	}
+
+	if caps.ProtocolVersion != mcp.LATEST_PROTOCOL_VERSION {
+		t.Errorf("ProtocolVersion = %q, want %q", caps.ProtocolVersion, mcp.LATEST_PROTOCOL_VERSION)
+	}
+	if caps.ServerInfo.Name != "capabilities-test" || caps.ServerInfo.Version != "2.3.4" {
+		t.Errorf("ServerInfo = %+v, want capabilities-test 2.3.4", caps.ServerInfo)
+	}
+	if caps.Capabilities.Tools == nil || !caps.Capabilities.Tools.ListChanged {
+		t.Errorf("expected tools capability with listChanged, got %+v", caps.Capabilities.Tools)
+	}
+	if caps.Capabilities.Resources == nil || !caps.Capabilities.Resources.Subscribe || caps.Capabilities.Resources.ListChanged {
+		t.Errorf("expected resources capability with subscribe only, got %+v", caps.Capabilities.Resources)
+	}
+	if caps.Capabilities.Prompts != nil {
+		t.Errorf("expected no prompts capability, got %+v", caps.Capabilities.Prompts)
+	}
+	if caps.Capabilities.Logging != nil {
+		t.Errorf("expected no logging capability, got %+v", caps.Capabilities.Logging)
+	}
+}
+
+func TestInfoEndpoint(t *testing.T) {
+	memoryTools := memory.NewService(NewMockVectorIndex(), memory.DefaultConfig()).Tools()
+	papersTools := papers.NewService(NewMockRedisClient(), papers.DefaultConfig()).Tools()
+
+	tests := []struct {
+		name  string
+		store string
+		tools [][]server.ServerTool
+		// some are tool names the server must report.
+		some []string
+	}{
+		{name: "memory-mcp", store: "upstash-vector", tools: [][]server.ServerTool{memoryTools}, some: []string{"add-to-memory", "search-memory"}},
+		{name: "research-papers-memory", store: "redis", tools: [][]server.ServerTool{papersTools}, some: []string{"get-research-paper", "list-papers"}},
+		{name: "combined-mcp", store: "upstash-vector+redis", tools: [][]server.ServerTool{memoryTools, papersTools}, some: []string{"add-to-memory", "get-research-paper"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := server.NewMCPServer(tt.name, "1.2.3", server.WithToolCapabilities(true))
+			want := []string{"capabilities"}
+			for _, tools := range tt.tools {
+				s.AddTools(tools...)
+				for _, tool := range tools {
+					want = append(want, tool.Tool.Name)
+				}
+			}
+			s.AddTools(serverinfo.CapabilitiesTool())
+			slices.Sort(want)
+
+			mux := http.NewServeMux()
+			serverinfo.Register(mux, s, "sse", tt.store)
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL + "/info")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Got status %d, want 200", resp.StatusCode)
+			}
+
+			var info serverinfo.Info
+			if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+				t.Fatal("Decoding body:", err)
+			}
+			if info.Name != tt.name || info.Version != "1.2.3" || info.Transport != "sse" || info.Store != tt.store {
+				t.Errorf("Unexpected info: %+v", info)
+			}
+			if !slices.Equal(info.Tools, want) {
+				t.Errorf("Got tools %v, want %v", info.Tools, want)
+			}
+			for _, name := range tt.some {
+				if !slices.Contains(info.Tools, name) {
+					t.Errorf("Expected tool %q in %v", name, info.Tools)
+				}
+			}
+		})
+	}
+}
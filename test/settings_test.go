@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/MikeLuu99/go-mcp/internal/metrics"
+	"github.com/MikeLuu99/go-mcp/internal/middleware"
+	"github.com/MikeLuu99/go-mcp/internal/settings"
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sharedVars are the variables settings.FromEnv reads, cleared so the
+// tests see the defaults.
+var sharedVars = []string{
+	"PORT", "MCP_TRANSPORT", "TRUSTED_PROXIES", "TLS_CERT_FILE", "TLS_KEY_FILE",
+	"MCP_AUTH_TOKEN", "MCP_AUTH_TOKENS_FILE", "ALLOWED_ORIGINS", "LOG_LEVEL",
+	"RETRY_ATTEMPTS", "RETRY_BASE_DELAY", "RETRY_MAX_DELAY", "RETRY_JITTER", "SHUTDOWN_TIMEOUT", "READY_TIMEOUT", "STARTUP_TIMEOUT",
+	"TOOL_TIMEOUT_MS", "RATE_LIMIT_PER_MIN", "HEALTH_PATH", "READY_PATH", "METRICS_PATH",
+}
+
+func TestSharedSettings(t *testing.T) {
+	for _, key := range sharedVars {
+		t.Setenv(key, "")
+	}
+
+	shared, err := settings.FromEnv(9090)
+	if err != nil {
+		t.Fatal("FromEnv:", err)
+	}
+	if shared.Port != 9090 || shared.Transport != transport.SSE {
+		t.Errorf("Expected port 9090 over %s, got %d over %s", transport.SSE, shared.Port, shared.Transport)
+	}
+	if shared.AuthTokens != nil || shared.RateLimit != 0 {
+		t.Errorf("Expected auth and rate limiting off, got %v and %d", shared.AuthTokens, shared.RateLimit)
+	}
+	if shared.ToolTimeout != 30*time.Second || shared.ShutdownTimeout != 10*time.Second {
+		t.Errorf("Expected the default timeouts, got tool %s and shutdown %s", shared.ToolTimeout, shared.ShutdownTimeout)
+	}
+
+	t.Setenv("PORT", "7000")
+	t.Setenv("TOOL_TIMEOUT_MS", "1500")
+	t.Setenv("RATE_LIMIT_PER_MIN", "60")
+	t.Setenv("ALLOWED_ORIGINS", "https://a.example, https://b.example")
+	shared, err = settings.FromEnv(9090)
+	if err != nil {
+		t.Fatal("FromEnv:", err)
+	}
+	if shared.Port != 7000 || shared.ToolTimeout != 1500*time.Millisecond || shared.RateLimit != 60 || len(shared.AllowedOrigins) != 2 {
+		t.Errorf("Unexpected settings: %+v", shared)
+	}
+
+	t.Setenv("HEALTH_PATH", "/ops/live")
+	t.Setenv("METRICS_PATH", "/ops/metrics")
+	shared, err = settings.FromEnv(9090)
+	if err != nil {
+		t.Fatal("FromEnv:", err)
+	}
+	if shared.HealthPaths.Health != "/ops/live" || shared.HealthPaths.Ready != "/readyz" || shared.MetricsPath != "/ops/metrics" {
+		t.Errorf("Unexpected paths: %+v and %s", shared.HealthPaths, shared.MetricsPath)
+	}
+
+	// The operational paths may not collide with each other or with the
+	// MCP transport.
+	for name, paths := range map[string][2]string{
+		"same as another":  {"READY_PATH", "/ops/live"},
+		"SSE endpoint":     {"METRICS_PATH", "/mcp/sse"},
+		"Streamable HTTP":  {"READY_PATH", "/mcp"},
+		"info endpoint":    {"READY_PATH", "/info"},
+		"catch-all":        {"METRICS_PATH", "/"},
+		"relative path":    {"METRICS_PATH", "metrics"},
+		"wildcard segment": {"READY_PATH", "/ready/{id}"},
+	} {
+		t.Setenv(paths[0], paths[1])
+		if _, err := settings.FromEnv(9090); err == nil || !strings.Contains(err.Error(), paths[0]) {
+			t.Errorf("%s: expected an error reporting %s, got %v", name, paths[0], err)
+		}
+		t.Setenv(paths[0], "")
+	}
+
+	// Every invalid setting is reported, not just the first.
+	t.Setenv("RATE_LIMIT_PER_MIN", "-1")
+	t.Setenv("PORT", "0")
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	t.Setenv("TOOL_TIMEOUT_MS", "fast")
+	_, err = settings.FromEnv(9090)
+	if err == nil {
+		t.Fatal("Expected error but got none")
+	}
+	for _, want := range []string{"RATE_LIMIT_PER_MIN", "PORT", "SHUTDOWN_TIMEOUT", "TOOL_TIMEOUT_MS"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to report %s, got: %v", want, err)
+		}
+	}
+}
+
+func TestSharedMiddleware(t *testing.T) {
+	shared := &settings.Shared{ToolTimeout: time.Second, RateLimit: 1}
+	handler := middleware.Chain(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		panic("deliberate")
+	}, shared.Middleware(logging.New(io.Discard, slog.LevelInfo), metrics.New())...)
+
+	// Recovery sits inside the timeout, so a panic on the handler's own
+	// goroutine still becomes an error result.
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "internal error") {
+		t.Fatalf("Expected the panic as an error result, got %+v, %v", result, err)
+	}
+	result, err = handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil || !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "rate limit exceeded") {
+		t.Errorf("Expected the second call to be rate limited, got %+v, %v", result, err)
+	}
+}
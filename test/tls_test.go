@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MikeLuu99/go-mcp/internal/lifecycle"
+)
+
+func TestTLSFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	for _, path := range []string{cert, key} {
+		if err := os.WriteFile(path, []byte("pem"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		enabled bool
+		err     string
+	}{
+		{name: "neither set"},
+		{name: "only cert", cert: cert},
+		{name: "only key", key: key},
+		{name: "both set", cert: cert, key: key, enabled: true},
+		{name: "missing cert", cert: filepath.Join(dir, "nope.pem"), key: key, err: "TLS_CERT_FILE is not readable"},
+		{name: "missing key", cert: cert, key: filepath.Join(dir, "nope.pem"), err: "TLS_KEY_FILE is not readable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TLS_CERT_FILE", tt.cert)
+			t.Setenv("TLS_KEY_FILE", tt.key)
+
+			files, err := lifecycle.TLSFromEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if files.Enabled() != tt.enabled {
+				t.Errorf("Got TLS enabled %v, want %v", files.Enabled(), tt.enabled)
+			}
+			if tt.enabled && (files.CertFile != cert || files.KeyFile != key) {
+				t.Errorf("Got files %+v", files)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MikeLuu99/go-mcp/internal/logging"
+	"github.com/MikeLuu99/go-mcp/internal/retry"
+	"github.com/MikeLuu99/go-mcp/internal/serverinfo"
+	"github.com/MikeLuu99/go-mcp/internal/settings"
+	"github.com/MikeLuu99/go-mcp/internal/transport"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTransportFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		err   bool
+	}{
+		{value: "", want: transport.SSE},
+		{value: "sse", want: transport.SSE},
+		{value: "streamable-http", want: transport.StreamableHTTP},
+		{value: "stdio", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("MCP_TRANSPORT", tt.value)
+		got, err := transport.FromEnv()
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected error but got none", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.value, err)
+		} else if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestStreamableHTTPTransport(t *testing.T) {
+	ctx := context.Background()
+
+	s := server.NewMCPServer("transport-test", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTools(serverinfo.CapabilitiesTool())
+
+	// Mounted on a mux beside other endpoints, as in the cmd programs.
+	httpServer := &http.Server{}
+	mcpServer, err := transport.New(transport.StreamableHTTP, s, httpServer, nil)
+	if err != nil {
+		t.Fatal("New:", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(transport.Pattern(mcpServer), mcpServer)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	endpoints := transport.Endpoints(mcpServer)
+	if len(endpoints) != 1 || endpoints[0] != "Streamable HTTP Endpoint: /mcp" {
+		t.Errorf("Got endpoints %q", endpoints)
+	}
+
+	c, err := client.NewStreamableHttpClient(ts.URL + transport.StreamableHTTPPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal("Start:", err)
+	}
+	var initReq mcp.InitializeRequest
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, initReq); err != nil {
+		t.Fatal("Initialize:", err)
+	}
+
+	got, err := callTool(ctx, c, "capabilities", nil)
+	if err != nil {
+		t.Fatal("CallTool:", err)
+	}
+	if !strings.Contains(got, "transport-test") {
+		t.Errorf("Expected the server name in the capabilities, got: %s", got)
+	}
+
+	if _, err := transport.New("stdio", s, httpServer, nil); err == nil {
+		t.Error("Expected error for an unknown transport but got none")
+	}
+}
+
+func TestSSETransportBesideOperationalEndpoints(t *testing.T) {
+	for _, key := range sharedVars {
+		t.Setenv(key, "")
+	}
+	t.Setenv("METRICS_PATH", "/ops/metrics")
+	shared, err := settings.FromEnv(9090)
+	if err != nil {
+		t.Fatal("FromEnv:", err)
+	}
+
+	// Built as the cmd programs build it: the SSE server has the static base
+	// path "/" and is mounted on "/" after the operational endpoints.
+	httpServer, _, err := shared.Build(settings.Server{
+		Name:    "transport-test",
+		Version: "1.0.0",
+		Store:   "none",
+	}, &settings.Backend{
+		Ping:     func(ctx context.Context) error { return nil },
+		Register: func(s *server.MCPServer, hooks *server.Hooks, policy retry.Policy) {},
+	}, logging.New(io.Discard, slog.LevelInfo), time.Now())
+	if err != nil {
+		t.Fatal("Build:", err)
+	}
+	ts := httptest.NewServer(httpServer.Handler)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + transport.SSEPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(line, "event: endpoint") {
+		t.Errorf("Got status %d and %q from the SSE endpoint, want the endpoint event", resp.StatusCode, line)
+	}
+
+	for path, want := range map[string]string{
+		"/ops/metrics": "# HELP",
+		"/healthz":     `"status":"ok"`,
+		"/readyz":      `"status":"ready"`,
+		"/info":        `"tools":["capabilities"]`,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), want) {
+			t.Errorf("GET %s: got status %d and %q, want %q", path, resp.StatusCode, body, want)
+		}
+	}
+
+	// The default metrics path is not served once it is moved; the SSE
+	// server answers it as an unknown route.
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Got status %d for the old metrics path, want 404", resp.StatusCode)
+	}
+}